@@ -6,11 +6,11 @@ hero_config = {
     type = 1,
     level = 10,
     exp = 1500,
-    growth_rate = 1.200000,
+    growth_rate = 1.2,
     is_unlocked = true,
     unlock_levels = {1, 5, 10, 15},
     tags = {"坦克", "近战", "物理"},
-    stat_multipliers = {1.100000, 1.200000, 1.000000, 1.150000},
+    stat_multipliers = {1.1, 1.2, 1.0, 1.15},
     resistance_types = {"物理", "魔法", "火焰"},
     resistance_values = {20, 15, 10},
     base_attr = {
@@ -32,7 +32,7 @@ hero_config = {
             quality = 2,
             level = 5,
             damage = 250,
-            cooldown = 3.000000,
+            cooldown = 3.0,
             description = "强力的剑技攻击"
         },
         {
@@ -41,7 +41,7 @@ hero_config = {
             quality = 3,
             level = 3,
             damage = 0,
-            cooldown = 8.000000,
+            cooldown = 8.0,
             description = "提升防御力的技能"
         }
     },
@@ -65,12 +65,12 @@ hero_config = {
         {
             item_id = 301,
             quantity = 100,
-            drop_rate = 1.000000
+            drop_rate = 1.0
         },
         {
             item_id = 302,
             quantity = 50,
-            drop_rate = 0.800000
+            drop_rate = 0.8
         }
     },
     friendship_hero_ids = {2, 3, 5},
@@ -96,11 +96,11 @@ hero_config = {
     type = 2,
     level = 12,
     exp = 2000,
-    growth_rate = 1.300000,
+    growth_rate = 1.3,
     is_unlocked = true,
     unlock_levels = {1, 8, 12, 18},
     tags = {"远程", "魔法", "AOE"},
-    stat_multipliers = {0.800000, 1.500000, 1.300000, 0.900000},
+    stat_multipliers = {0.8, 1.5, 1.3, 0.9},
     resistance_types = {"魔法", "精神", "冰霜"},
     resistance_values = {25, 30, 20},
     base_attr = {
@@ -122,7 +122,7 @@ hero_config = {
             quality = 1,
             level = 8,
             damage = 180,
-            cooldown = 2.500000,
+            cooldown = 2.5,
             description = "基础火系魔法"
         },
         {
@@ -131,7 +131,7 @@ hero_config = {
             quality = 3,
             level = 6,
             damage = 300,
-            cooldown = 6.000000,
+            cooldown = 6.0,
             description = "大范围冰系魔法"
         }
     },
@@ -155,12 +155,12 @@ hero_config = {
         {
             item_id = 401,
             quantity = 80,
-            drop_rate = 0.900000
+            drop_rate = 0.9
         },
         {
             item_id = 402,
             quantity = 30,
-            drop_rate = 0.700000
+            drop_rate = 0.7
         }
     },
     friendship_hero_ids = {1, 4, 6},
@@ -186,11 +186,11 @@ hero_config = {
     type = 3,
     level = 8,
     exp = 1200,
-    growth_rate = 1.100000,
+    growth_rate = 1.1,
     is_unlocked = false,
     unlock_levels = {1, 6, 11, 16},
     tags = {"远程", "敏捷", "精准"},
-    stat_multipliers = {1.300000, 1.400000, 0.900000, 1.000000},
+    stat_multipliers = {1.3, 1.4, 0.9, 1.0},
     resistance_types = {"风", "自然"},
     resistance_values = {15, 25, 0},
     base_attr = {
@@ -212,7 +212,7 @@ hero_config = {
             quality = 1,
             level = 4,
             damage = 120,
-            cooldown = 4.000000,
+            cooldown = 4.0,
             description = "同时射出多支箭"
         },
         {
@@ -221,7 +221,7 @@ hero_config = {
             quality = 2,
             level = 7,
             damage = 200,
-            cooldown = 5.000000,
+            cooldown = 5.0,
             description = "穿透护甲的特殊箭矢"
         }
     },
@@ -245,12 +245,12 @@ hero_config = {
         {
             item_id = 501,
             quantity = 60,
-            drop_rate = 0.800000
+            drop_rate = 0.8
         },
         {
             item_id = 502,
             quantity = 40,
-            drop_rate = 0.600000
+            drop_rate = 0.6
         }
     },
     friendship_hero_ids = {1, 2, 7},
@@ -12,7 +12,7 @@ $hero_config = [
     'is_unlocked' => true,
     'unlock_levels' => [1, 5, 10, 15],
     'tags' => ['坦克', '近战', '物理'],
-    'stat_multipliers' => [1.1, 1.2, 1, 1.15],
+    'stat_multipliers' => [1.1, 1.2, 1.0, 1.15],
     'resistance_types' => ['物理', '魔法', '火焰'],
     'resistance_values' => [20, 15, 10],
     'base_attr' => [
@@ -34,7 +34,7 @@ $hero_config = [
             'quality' => 2,
             'level' => 5,
             'damage' => 250,
-            'cooldown' => 3,
+            'cooldown' => 3.0,
             'description' => '强力的剑技攻击'
         ],
         [
@@ -43,7 +43,7 @@ $hero_config = [
             'quality' => 3,
             'level' => 3,
             'damage' => 0,
-            'cooldown' => 8,
+            'cooldown' => 8.0,
             'description' => '提升防御力的技能'
         ]
     ],
@@ -67,7 +67,7 @@ $hero_config = [
         [
             'item_id' => 301,
             'quantity' => 100,
-            'drop_rate' => 1
+            'drop_rate' => 1.0
         ],
         [
             'item_id' => 302,
@@ -133,7 +133,7 @@ $hero_config = [
             'quality' => 3,
             'level' => 6,
             'damage' => 300,
-            'cooldown' => 6,
+            'cooldown' => 6.0,
             'description' => '大范围冰系魔法'
         ]
     ],
@@ -192,7 +192,7 @@ $hero_config = [
     'is_unlocked' => false,
     'unlock_levels' => [1, 6, 11, 16],
     'tags' => ['远程', '敏捷', '精准'],
-    'stat_multipliers' => [1.3, 1.4, 0.9, 1],
+    'stat_multipliers' => [1.3, 1.4, 0.9, 1.0],
     'resistance_types' => ['风', '自然'],
     'resistance_values' => [15, 25, 0],
     'base_attr' => [
@@ -214,7 +214,7 @@ $hero_config = [
             'quality' => 1,
             'level' => 4,
             'damage' => 120,
-            'cooldown' => 4,
+            'cooldown' => 4.0,
             'description' => '同时射出多支箭'
         ],
         [
@@ -223,7 +223,7 @@ $hero_config = [
             'quality' => 2,
             'level' => 7,
             'damage' => 200,
-            'cooldown' => 5,
+            'cooldown' => 5.0,
             'description' => '穿透护甲的特殊箭矢'
         ]
     ],
@@ -4,15 +4,152 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"os"
 	"path/filepath"
 	"protoxls/protoxls"
 	"strings"
 )
 
+// parseImportPaths splits the import path flag into a list of directories
+func parseImportPaths(importPaths string) []string {
+	if importPaths == "" {
+		return []string{"."}
+	}
+	if strings.Contains(importPaths, ":") {
+		// Unix-style colon-separated paths
+		return strings.Split(importPaths, ":")
+	}
+	// Use system-specific path list separator
+	return filepath.SplitList(importPaths)
+}
+
+// runTemplateCommand generates Excel templates from the proto schema
+func runTemplateCommand(args []string) {
+	flags := flag.NewFlagSet("template", flag.ExitOnError)
+	protoFilePath := flags.String("proto", "scheme.proto", "Path to the .proto file to parse")
+	importPaths := flags.String("I", ".", "Import paths for .proto files (colon-separated)")
+	messageName := flags.String("message", "", "Only generate the template for this message (defaults to all messages with excel option)")
+	outputPath := flags.String("o", "", "Output workbook path (defaults to the excel option of each message)")
+	arraySlots := flags.Int("slots", protoxls.DefaultTemplateArraySlots, "Number of indexed column groups for message arrays")
+	overwrite := flags.Bool("force", false, "Overwrite existing workbooks")
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s template [options] -proto <proto_file>\n\n", "protoxls")
+		fmt.Fprintf(flags.Output(), "Generate Excel templates with headers, comments and enum dropdowns.\n\n")
+		fmt.Fprintf(flags.Output(), "Options:\n")
+		flags.PrintDefaults()
+		fmt.Fprintf(flags.Output(), "\nExamples:\n")
+		fmt.Fprintf(flags.Output(), "  %s template -proto config.proto                          # Write templates to the excel option paths\n", "protoxls")
+		fmt.Fprintf(flags.Output(), "  %s template -proto config.proto -message HeroConfig -o hero.xlsx -slots 3\n", "protoxls")
+	}
+	flags.Parse(args)
+
+	templateConfig := &protoxls.TemplateConfig{
+		OutputPath:  *outputPath,
+		MessageName: *messageName,
+		ArraySlots:  *arraySlots,
+		Overwrite:   *overwrite,
+	}
+
+	if err := protoxls.GenerateTemplateFiles(*protoFilePath, parseImportPaths(*importPaths), templateConfig); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// runSyncCommand syncs existing workbook headers with the proto schema
+func runSyncCommand(args []string) {
+	flags := flag.NewFlagSet("sync", flag.ExitOnError)
+	protoFilePath := flags.String("proto", "scheme.proto", "Path to the .proto file to parse")
+	importPaths := flags.String("I", ".", "Import paths for .proto files (colon-separated)")
+	messageName := flags.String("message", "", "Only sync the workbook of this message (defaults to all messages with excel option)")
+	arraySlots := flags.Int("slots", protoxls.DefaultTemplateArraySlots, "Number of indexed column groups for newly added message arrays")
+	removeDeleted := flags.Bool("remove", false, "Remove columns of deleted fields instead of flagging them")
+	dryRun := flags.Bool("dry_run", false, "Only print the planned changes without saving")
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s sync [options] -proto <proto_file>\n\n", "protoxls")
+		fmt.Fprintf(flags.Output(), "Sync workbook headers with the schema without touching data cells.\n\n")
+		fmt.Fprintf(flags.Output(), "Options:\n")
+		flags.PrintDefaults()
+		fmt.Fprintf(flags.Output(), "\nExamples:\n")
+		fmt.Fprintf(flags.Output(), "  %s sync -proto config.proto -dry_run              # Show planned header changes\n", "protoxls")
+		fmt.Fprintf(flags.Output(), "  %s sync -proto config.proto -message HeroConfig -remove\n", "protoxls")
+	}
+	flags.Parse(args)
+
+	syncConfig := &protoxls.SyncConfig{
+		MessageName:   *messageName,
+		ArraySlots:    *arraySlots,
+		RemoveDeleted: *removeDeleted,
+		DryRun:        *dryRun,
+	}
+
+	if err := protoxls.SyncWorkbookFiles(*protoFilePath, parseImportPaths(*importPaths), syncConfig); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// runImportCommand writes exported JSON or YAML data back into the table workbook
+func runImportCommand(args []string) {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	protoFilePath := flags.String("proto", "scheme.proto", "Path to the .proto file to parse")
+	importPaths := flags.String("I", ".", "Import paths for .proto files (colon-separated)")
+	inputPath := flags.String("in", "", "Exported JSON or YAML file to import")
+	format := flags.String("format", "", "Input format: json or yaml (defaults to the file extension)")
+	messageName := flags.String("message", "", "Table message of the input (defaults to the table matching the file name)")
+	arraySlots := flags.Int("slots", protoxls.DefaultTemplateArraySlots, "Number of indexed column groups when a new workbook is created")
+	replace := flags.Bool("replace", false, "Replace all data rows instead of updating rows by primary key")
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s import [options] -proto <proto_file> -in <data_file>\n\n", "protoxls")
+		fmt.Fprintf(flags.Output(), "Write exported JSON or YAML data back into the table workbook.\n\n")
+		fmt.Fprintf(flags.Output(), "Options:\n")
+		flags.PrintDefaults()
+		fmt.Fprintf(flags.Output(), "\nExamples:\n")
+		fmt.Fprintf(flags.Output(), "  %s import -proto config.proto -in output/hero_config.json     # Update rows by primary key\n", "protoxls")
+		fmt.Fprintf(flags.Output(), "  %s import -proto config.proto -in levels.yaml -message LevelConfig -replace\n", "protoxls")
+	}
+	flags.Parse(args)
+
+	if *inputPath == "" {
+		fmt.Fprintf(flags.Output(), "Error: -in flag is required\n\n")
+		flags.Usage()
+		return
+	}
+
+	importConfig := &protoxls.ImportConfig{
+		InputPath:   *inputPath,
+		Format:      *format,
+		MessageName: *messageName,
+		ArraySlots:  *arraySlots,
+		Replace:     *replace,
+	}
+
+	if err := protoxls.ImportDataFiles(*protoFilePath, parseImportPaths(*importPaths), importConfig); err != nil {
+		log.Fatal(err)
+	}
+}
+
 func main() {
+	// Dispatch subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "template":
+			runTemplateCommand(os.Args[2:])
+			return
+		case "sync":
+			runSyncCommand(os.Args[2:])
+			return
+		case "import":
+			runImportCommand(os.Args[2:])
+			return
+		}
+	}
+
 	// Proto file and import paths
 	protoFilePath := flag.String("proto", "scheme.proto", "Path to the .proto file to parse")
 	importPaths := flag.String("I", ".", "Import paths for .proto files (colon-separated)")
+	descriptorSet := flag.String("descriptor_set", "", "Load the schema from a serialized FileDescriptorSet (protoc -o --include_imports) instead of -proto")
 
 	// Output format flags (similar to protoc)
 	luaOut := flag.String("lua_out", "", "Generate Lua files in the specified directory")
@@ -20,13 +157,46 @@ func main() {
 	binOut := flag.String("bin_out", "", "Generate binary files in the specified directory")
 	yamlOut := flag.String("yaml_out", "", "Generate YAML files in the specified directory")
 	phpOut := flag.String("php_out", "", "Generate PHP files in the specified directory")
+	jsonSchemaOut := flag.String("jsonschema_out", "", "Generate JSON Schema files describing the JSON output in the specified directory")
+	jsonLinesOut := flag.String("jsonl_out", "", "Generate JSON Lines files with one row per line in the specified directory")
+	textProtoOut := flag.String("txtpb_out", "", "Generate protobuf text format files in the specified directory")
+	csharpOut := flag.String("csharp_out", "", "Generate C# classes and loaders in the specified directory")
+	goOut := flag.String("go_out", "", "Generate Go structs and loaders in the specified directory")
+	tsOut := flag.String("ts_out", "", "Generate TypeScript declarations in the specified directory")
+	pyOut := flag.String("py_out", "", "Generate Python modules in the specified directory")
+	descriptorSetOut := flag.String("descriptor_set_out", "", "Write the FileDescriptorSet of the proto files defining the tables to the specified file")
 	allOut := flag.String("all_out", "", "Generate all format files in the specified directory")
 
 	// Format options
 	compactFormat := flag.Bool("compact", false, "Compress each data entry to a single line (applies to lua, json, php formats)")
+	luaModule := flag.Bool("lua_module", false, "Emit Lua files as modules returning a local table instead of assigning a global (applies to lua format)")
+	luaReadOnly := flag.Bool("lua_readonly", false, "Wrap exported Lua tables in recursive read-only proxies (applies to lua format)")
+	luaOptimize := flag.Bool("lua_optimize", false, "Omit default values and share identical subtables in Lua files (applies to lua format)")
+	luaChunkConstants := flag.Int("lua_chunk_constants", protoxls.MaxLuaChunkConstants, "Split Lua tables into loader functions of at most this many estimated constants (applies to lua format)")
+	luaAnnotations := flag.Bool("lua_annotations", false, "Generate EmmyLua / LuaLS annotation files next to the Lua files (applies to lua format)")
+	jsonLinesKey := flag.String("jsonl_key", "", "Add the key path of each row as a field with this name (applies to jsonl format)")
+	exportEnums := flag.Bool("enums", false, "Write the enum definitions used by the tables to an enums file per format (applies to lua, json, yaml, php formats)")
+	phpEnumStyle := flag.String("php_enum_style", protoxls.PhpEnumStyleClass, "PHP enum definition style: class (classes with constants) or enum (PHP 8.1 backed enums)")
+	enumFormat := flag.String("enum_format", protoxls.EnumFormatNumber, "How enum fields are exported unless set by the (enum_format) field option: number, name or alias (applies to lua, json, yaml, php formats)")
+	binFormat := flag.String("bin_format", protoxls.BinFormatRaw, "Binary file layout: raw (length-prefixed rows), container (self-describing header, rows and checksum), wrapper (message with a repeated rows field) or indexed (rows after an index sorted by key)")
+	binDescriptors := flag.Bool("bin_descriptors", false, "Embed the FileDescriptorSet of the table in binary containers (applies to bin format with -bin_format=container)")
+	csharpNamespace := flag.String("csharp_namespace", "", "Namespace of the generated C# code, defaults to the csharp_namespace option or package of each proto file (applies to csharp format)")
+	csharpNaming := flag.String("csharp_naming", protoxls.CSharpNamingPascal, "C# naming convention: pascal (PascalCase properties and enum values) or proto (proto names) (applies to csharp format)")
+	csharpLoader := flag.String("csharp_loader", protoxls.CSharpLoaderJSON, "Output read by the generated C# loaders: json (with Newtonsoft.Json) or bin (any -bin_format) (applies to csharp format)")
+	goPackage := flag.String("go_package", "", "Package name of the generated Go code, defaults to the name of the -go_out directory (applies to go format)")
+	goLoader := flag.String("go_loader", protoxls.GoLoaderJSON, "Output read by the generated Go loaders: json (with encoding/json) or bin (any -bin_format) (applies to go format)")
+	goEmbed := flag.Bool("go_embed", false, "Write the data next to the Go code and embed it with go:embed, adding typed lookup functions (applies to go format)")
+	tsMode := flag.String("ts_mode", protoxls.TsModeTypes, "TypeScript output: types (declarations typing the JSON output) or module (declarations and ES modules exporting the data) (applies to ts format)")
+	pyTypes := flag.String("py_types", protoxls.PyTypesDataclass, "Python type definitions: dataclass (rows are dataclass instances) or typeddict (rows are dicts) (applies to py format)")
+	includeSourceInfo := flag.Bool("include_source_info", false, "Keep source info such as comments in the descriptor set (applies to -descriptor_set_out)")
+	jsonStyle := flag.String("json_style", protoxls.JsonStyleDefault, "JSON mapping style: default (proto field names, enum numbers) or protojson (canonical proto3 JSON)")
 
 	flag.Usage = func() {
-		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [options] -proto <proto_file>\n\n", "protoxls")
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [options] -proto <proto_file>\n", "protoxls")
+		fmt.Fprintf(flag.CommandLine.Output(), "       %s [options] -descriptor_set <descriptor_set_file>\n", "protoxls")
+		fmt.Fprintf(flag.CommandLine.Output(), "       %s template [options] -proto <proto_file>\n", "protoxls")
+		fmt.Fprintf(flag.CommandLine.Output(), "       %s sync [options] -proto <proto_file>\n", "protoxls")
+		fmt.Fprintf(flag.CommandLine.Output(), "       %s import [options] -proto <proto_file> -in <data_file>\n\n", "protoxls")
 		fmt.Fprintf(flag.CommandLine.Output(), "Protocol buffer configuration table generator.\n\n")
 		fmt.Fprintf(flag.CommandLine.Output(), "Options:\n")
 		flag.PrintDefaults()
@@ -38,34 +208,111 @@ func main() {
 		fmt.Fprintf(flag.CommandLine.Output(), "  %s -proto config.proto -php_out=./output       # Generate PHP files in ./output\n", "protoxls")
 		fmt.Fprintf(flag.CommandLine.Output(), "  %s -proto config.proto -lua_out=./output -json_out=./output  # Generate multiple formats\n", "protoxls")
 		fmt.Fprintf(flag.CommandLine.Output(), "  %s -proto config.proto -all_out=./output -compact           # Generate all formats compactly\n", "protoxls")
+		fmt.Fprintf(flag.CommandLine.Output(), "  %s -proto config.proto -json_out=./output -json_style=protojson  # Generate canonical proto3 JSON\n", "protoxls")
+		fmt.Fprintf(flag.CommandLine.Output(), "  %s -proto config.proto -json_out=./output -jsonschema_out=./schema  # Generate JSON with schemas\n", "protoxls")
+		fmt.Fprintf(flag.CommandLine.Output(), "  %s -proto config.proto -jsonl_out=./output -jsonl_key=_key  # Generate one row per line with key paths\n", "protoxls")
+		fmt.Fprintf(flag.CommandLine.Output(), "  %s -proto config.proto -txtpb_out=./review  # Generate protobuf text format files for review\n", "protoxls")
+		fmt.Fprintf(flag.CommandLine.Output(), "  %s -proto config.proto -bin_out=./Assets/Config -csharp_out=./Assets/Scripts/Config -csharp_loader=bin  # Generate C# classes loading the binary files\n", "protoxls")
+		fmt.Fprintf(flag.CommandLine.Output(), "  %s -proto config.proto -go_out=./internal/config -go_embed  # Generate a Go package embedding the tables\n", "protoxls")
+		fmt.Fprintf(flag.CommandLine.Output(), "  %s -proto config.proto -ts_out=./src/config -ts_mode=module  # Generate typed TypeScript modules with the data\n", "protoxls")
+		fmt.Fprintf(flag.CommandLine.Output(), "  %s -proto config.proto -py_out=./tools/config  # Generate Python modules for tooling\n", "protoxls")
+		fmt.Fprintf(flag.CommandLine.Output(), "  %s -proto config.proto -lua_out=./output -lua_module -lua_readonly  # Generate read-only Lua modules\n", "protoxls")
+		fmt.Fprintf(flag.CommandLine.Output(), "  %s -proto config.proto -lua_out=./output -lua_optimize     # Generate smaller Lua files\n", "protoxls")
+		fmt.Fprintf(flag.CommandLine.Output(), "  %s -proto config.proto -lua_out=./output -lua_annotations  # Generate Lua files with type annotations\n", "protoxls")
+		fmt.Fprintf(flag.CommandLine.Output(), "  %s -proto config.proto -all_out=./output -enums            # Generate all formats with enum definitions\n", "protoxls")
+		fmt.Fprintf(flag.CommandLine.Output(), "  %s -proto config.proto -yaml_out=./output -enum_format=alias  # Generate YAML with enum display names\n", "protoxls")
+		fmt.Fprintf(flag.CommandLine.Output(), "  %s -proto config.proto -bin_out=./output -bin_format=container -bin_descriptors  # Generate self-describing binary files\n", "protoxls")
+		fmt.Fprintf(flag.CommandLine.Output(), "  %s -proto config.proto -bin_out=./output -descriptor_set_out=./output/config.pb  # Generate binary files with their schema\n", "protoxls")
+		fmt.Fprintf(flag.CommandLine.Output(), "  %s -descriptor_set=schema.pb -all_out=./output  # Load the schema compiled by protoc -o schema.pb --include_imports\n", "protoxls")
 	}
 
 	flag.Parse()
 
 	// Validate required arguments
-	if *protoFilePath == "" {
+	if *protoFilePath == "" && *descriptorSet == "" {
 		fmt.Fprintf(flag.CommandLine.Output(), "Error: -proto flag is required\n\n")
 		flag.Usage()
 		return
 	}
 
 	// Parse import paths
-	var parsedImportPaths []string
-	if *importPaths != "" {
-		if strings.Contains(*importPaths, ":") {
-			// Unix-style colon-separated paths
-			parsedImportPaths = strings.Split(*importPaths, ":")
-		} else {
-			// Use system-specific path list separator
-			parsedImportPaths = filepath.SplitList(*importPaths)
-		}
-	} else {
-		parsedImportPaths = []string{"."}
-	}
+	parsedImportPaths := parseImportPaths(*importPaths)
 
 	// Configure export options
 	exportConfig := &protoxls.ExportConfig{
-		CompactFormat: *compactFormat,
+		CompactFormat:     *compactFormat,
+		JsonStyle:         *jsonStyle,
+		JsonLinesKey:      *jsonLinesKey,
+		LuaModule:         *luaModule,
+		LuaReadOnly:       *luaReadOnly,
+		LuaOptimize:       *luaOptimize,
+		LuaChunkConstants: *luaChunkConstants,
+		LuaAnnotations:    *luaAnnotations,
+		ExportEnums:       *exportEnums,
+		PhpEnumStyle:      *phpEnumStyle,
+		EnumFormat:        *enumFormat,
+		BinFormat:         *binFormat,
+		BinDescriptors:    *binDescriptors,
+		IncludeSourceInfo: *includeSourceInfo,
+		DescriptorSet:     *descriptorSet,
+		CSharpNamespace:   *csharpNamespace,
+		CSharpNaming:      *csharpNaming,
+		CSharpLoader:      *csharpLoader,
+		GoPackage:         *goPackage,
+		GoLoader:          *goLoader,
+		GoEmbed:           *goEmbed,
+		TsMode:            *tsMode,
+		PyTypes:           *pyTypes,
+	}
+
+	if *jsonStyle != protoxls.JsonStyleDefault && *jsonStyle != protoxls.JsonStyleProtoJSON {
+		fmt.Fprintf(flag.CommandLine.Output(), "Error: Unknown -json_style %q, use default or protojson\n\n", *jsonStyle)
+		flag.Usage()
+		return
+	}
+	if !protoxls.IsValidEnumFormat(*enumFormat) {
+		fmt.Fprintf(flag.CommandLine.Output(), "Error: Unknown -enum_format %q, use number, name or alias\n\n", *enumFormat)
+		flag.Usage()
+		return
+	}
+	if !protoxls.IsValidBinFormat(*binFormat) {
+		fmt.Fprintf(flag.CommandLine.Output(), "Error: Unknown -bin_format %q, use raw, container, wrapper or indexed\n\n", *binFormat)
+		flag.Usage()
+		return
+	}
+	if *phpEnumStyle != protoxls.PhpEnumStyleClass && *phpEnumStyle != protoxls.PhpEnumStyleEnum {
+		fmt.Fprintf(flag.CommandLine.Output(), "Error: Unknown -php_enum_style %q, use class or enum\n\n", *phpEnumStyle)
+		flag.Usage()
+		return
+	}
+
+	if !protoxls.IsValidCSharpNaming(*csharpNaming) {
+		fmt.Fprintf(flag.CommandLine.Output(), "Error: Unknown -csharp_naming %q, use pascal or proto\n\n", *csharpNaming)
+		flag.Usage()
+		return
+	}
+	if !protoxls.IsValidCSharpLoader(*csharpLoader) {
+		fmt.Fprintf(flag.CommandLine.Output(), "Error: Unknown -csharp_loader %q, use json or bin\n\n", *csharpLoader)
+		flag.Usage()
+		return
+	}
+
+	if !protoxls.IsValidGoLoader(*goLoader) {
+		fmt.Fprintf(flag.CommandLine.Output(), "Error: Unknown -go_loader %q, use json or bin\n\n", *goLoader)
+		flag.Usage()
+		return
+	}
+
+	if !protoxls.IsValidTsMode(*tsMode) {
+		fmt.Fprintf(flag.CommandLine.Output(), "Error: Unknown -ts_mode %q, use types or module\n\n", *tsMode)
+		flag.Usage()
+		return
+	}
+
+	if !protoxls.IsValidPyTypes(*pyTypes) {
+		fmt.Fprintf(flag.CommandLine.Output(), "Error: Unknown -py_types %q, use dataclass or typeddict\n\n", *pyTypes)
+		flag.Usage()
+		return
 	}
 
 	// Handle all_out option
@@ -83,10 +330,18 @@ func main() {
 		exportConfig.YamlOutput = *yamlOut
 		exportConfig.PhpOutput = *phpOut
 	}
+	exportConfig.JsonSchemaOutput = *jsonSchemaOut
+	exportConfig.JsonLinesOutput = *jsonLinesOut
+	exportConfig.TextProtoOutput = *textProtoOut
+	exportConfig.CSharpOutput = *csharpOut
+	exportConfig.GoOutput = *goOut
+	exportConfig.TsOutput = *tsOut
+	exportConfig.PyOutput = *pyOut
+	exportConfig.DescriptorSetOutput = *descriptorSetOut
 
 	// Check if any output format is specified
-	if exportConfig.LuaOutput == "" && exportConfig.JsonOutput == "" && exportConfig.BinOutput == "" && exportConfig.YamlOutput == "" && exportConfig.PhpOutput == "" {
-		fmt.Fprintf(flag.CommandLine.Output(), "Error: No output format specified. Use one of: -lua_out, -json_out, -bin_out, -yaml_out, -php_out, or -all_out\n\n")
+	if exportConfig.LuaOutput == "" && exportConfig.JsonOutput == "" && exportConfig.BinOutput == "" && exportConfig.YamlOutput == "" && exportConfig.PhpOutput == "" && exportConfig.JsonSchemaOutput == "" && exportConfig.JsonLinesOutput == "" && exportConfig.TextProtoOutput == "" && exportConfig.CSharpOutput == "" && exportConfig.GoOutput == "" && exportConfig.TsOutput == "" && exportConfig.PyOutput == "" && exportConfig.DescriptorSetOutput == "" {
+		fmt.Fprintf(flag.CommandLine.Output(), "Error: No output format specified. Use one of: -lua_out, -json_out, -bin_out, -yaml_out, -php_out, -jsonschema_out, -jsonl_out, -txtpb_out, -csharp_out, -go_out, -ts_out, -py_out, -descriptor_set_out, or -all_out\n\n")
 		flag.Usage()
 		return
 	}
@@ -98,5 +353,9 @@ func main() {
 	}
 
 	// Print success message
-	fmt.Printf("Successfully processed %s\n", *protoFilePath)
+	if *descriptorSet != "" {
+		fmt.Printf("Successfully processed %s\n", *descriptorSet)
+	} else {
+		fmt.Printf("Successfully processed %s\n", *protoFilePath)
+	}
 }
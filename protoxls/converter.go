@@ -0,0 +1,109 @@
+package protoxls
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+)
+
+const (
+	// CompositeArraySeparator is the separator between elements of a single column array
+	// whose message type has a registered cell converter, e.g. "1,0,2;3,0,4"
+	CompositeArraySeparator = ";"
+)
+
+// CellConverter parses a single cell value into the given message
+type CellConverter func(cellValue string, message *dynamic.Message) error
+
+var (
+	cellConvertersMutex sync.RWMutex
+	cellConverters      = make(map[string]CellConverter)
+)
+
+// RegisterCellConverter registers a converter for the message with the given full name,
+// allowing values of that message type to be written in a single cell
+func RegisterCellConverter(messageName string, converter CellConverter) {
+	cellConvertersMutex.Lock()
+	defer cellConvertersMutex.Unlock()
+	if converter == nil {
+		delete(cellConverters, messageName)
+		return
+	}
+	cellConverters[messageName] = converter
+}
+
+// UnregisterCellConverter removes the converter registered for the message with the given full name
+func UnregisterCellConverter(messageName string) {
+	RegisterCellConverter(messageName, nil)
+}
+
+// GetCellConverter returns the converter registered for the message with the given full name
+func GetCellConverter(messageName string) (CellConverter, bool) {
+	cellConvertersMutex.RLock()
+	defer cellConvertersMutex.RUnlock()
+	converter, ok := cellConverters[messageName]
+	return converter, ok
+}
+
+// hasCellConverter checks if the message type of a field has a registered cell converter
+func hasCellConverter(field *desc.FieldDescriptor) bool {
+	if field.GetMessageType() == nil {
+		return false
+	}
+	_, ok := GetCellConverter(field.GetMessageType().GetFullyQualifiedName())
+	return ok
+}
+
+// convertCellMessage converts a cell value into a message using the registered cell converter
+func convertCellMessage(cellValue string, msgDesc *desc.MessageDescriptor) (*dynamic.Message, error) {
+	converter, ok := GetCellConverter(msgDesc.GetFullyQualifiedName())
+	if !ok {
+		return nil, fmt.Errorf("no cell converter registered for message %s", msgDesc.GetFullyQualifiedName())
+	}
+	message := dynamic.NewMessage(msgDesc)
+	if err := converter(cellValue, message); err != nil {
+		return nil, fmt.Errorf("failed to convert %s value '%s': %v", msgDesc.GetName(), cellValue, err)
+	}
+	return message, nil
+}
+
+// NewFieldListConverter returns a converter that assigns separator-delimited values
+// to the message fields in field number order, e.g. "1.5,0,2" for a Vector3 {x, y, z}
+func NewFieldListConverter(separator string) CellConverter {
+	// The fields of each message type are sorted once, when the first cell of that type is converted
+	var sortedFields sync.Map
+	return func(cellValue string, message *dynamic.Message) error {
+		msgDesc := message.GetMessageDescriptor()
+		cached, ok := sortedFields.Load(msgDesc)
+		if !ok {
+			cached, _ = sortedFields.LoadOrStore(msgDesc, getSortedFields(msgDesc))
+		}
+		fields := cached.([]*desc.FieldDescriptor)
+
+		values := strings.Split(cellValue, separator)
+		if len(values) > len(fields) {
+			return fmt.Errorf("too many values: got %d, message has %d fields", len(values), len(fields))
+		}
+
+		for i, val := range values {
+			val = strings.TrimSpace(val)
+			if val == "" {
+				continue // 允许为空
+			}
+
+			field := fields[i]
+			if field.IsRepeated() {
+				return fmt.Errorf("repeated field %s is not supported", field.GetName())
+			}
+			fieldValue, err := convertCellValue(val, field)
+			if err != nil {
+				return fmt.Errorf("field %s: %v", field.GetName(), err)
+			}
+			message.SetField(field, fieldValue)
+		}
+		return nil
+	}
+}
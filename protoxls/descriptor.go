@@ -0,0 +1,134 @@
+package protoxls
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/jhump/protoreflect/desc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// BuildFileDescriptorSet returns the files and all their dependencies as a FileDescriptorSet.
+// Dependencies come before the files importing them, as protoc writes them, and source info
+// such as comments is only kept if requested
+func BuildFileDescriptorSet(files []*desc.FileDescriptor, includeSourceInfo bool) *descriptorpb.FileDescriptorSet {
+	set := &descriptorpb.FileDescriptorSet{}
+	added := make(map[string]bool)
+
+	var add func(fd *desc.FileDescriptor)
+	add = func(fd *desc.FileDescriptor) {
+		if added[fd.GetName()] {
+			return
+		}
+		added[fd.GetName()] = true
+		for _, dep := range fd.GetDependencies() {
+			add(dep)
+		}
+
+		fdp := fd.AsFileDescriptorProto()
+		if !includeSourceInfo && fdp.SourceCodeInfo != nil {
+			fdp = proto.Clone(fdp).(*descriptorpb.FileDescriptorProto)
+			fdp.SourceCodeInfo = nil
+		}
+		set.File = append(set.File, fdp)
+	}
+
+	for _, fd := range files {
+		add(fd)
+	}
+	return set
+}
+
+// marshalFileDescriptorSet serializes a FileDescriptorSet deterministically
+func marshalFileDescriptorSet(set *descriptorpb.FileDescriptorSet) ([]byte, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(set)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal file descriptor set: %v", err)
+	}
+	return data, nil
+}
+
+// LoadDescriptorSet loads file descriptors from a serialized FileDescriptorSet, such as the output of
+// protoc -o, in place of parsing .proto sources. The set must hold all imports, see protoc --include_imports
+func LoadDescriptorSet(descriptorSetPath string) ([]*desc.FileDescriptor, error) {
+	data, err := ioutil.ReadFile(descriptorSetPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read descriptor set %s: %v", descriptorSetPath, err)
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(data, set); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal descriptor set %s: %v", descriptorSetPath, err)
+	}
+
+	filesByName, err := desc.CreateFileDescriptorsFromSet(set)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load descriptor set %s, it must include all imports: %v", descriptorSetPath, err)
+	}
+
+	// Keep the order of the set, the map returned above has none
+	fileDescriptors := make([]*desc.FileDescriptor, 0, len(set.File))
+	for _, fdp := range set.File {
+		fileDescriptors = append(fileDescriptors, filesByName[fdp.GetName()])
+	}
+	return fileDescriptors, nil
+}
+
+// WriteDescriptorSet writes the FileDescriptorSet of the files defining the tables to a file, like
+// protoc --descriptor_set_out, so the exported data can be decoded without the .proto sources
+func WriteDescriptorSet(stores []*TableStore, outputPath string, includeSourceInfo bool) error {
+	var files []*desc.FileDescriptor
+	seen := make(map[string]bool)
+	for _, store := range stores {
+		fd := store.GetMessageDescriptor().GetFile()
+		if !seen[fd.GetName()] {
+			seen[fd.GetName()] = true
+			files = append(files, fd)
+		}
+	}
+
+	data, err := marshalFileDescriptorSet(BuildFileDescriptorSet(files, includeSourceInfo))
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), DefaultFilePermissions); err != nil {
+		return fmt.Errorf("failed to create output directory: %v", err)
+	}
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create descriptor set file: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("failed to write descriptor set: %v", err)
+	}
+	fmt.Printf("Exported descriptor set file: %s\n", outputPath)
+	return nil
+}
+
+// schemaFingerprint returns the SHA-256 of the descriptors of the file defining a message and its
+// dependencies. Comments are left out, so only changes to the schema itself change the fingerprint
+func schemaFingerprint(msgDesc *desc.MessageDescriptor) ([sha256.Size]byte, error) {
+	data, err := marshalFileDescriptorSet(BuildFileDescriptorSet([]*desc.FileDescriptor{msgDesc.GetFile()}, false))
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(data), nil
+}
+
+// getSortedFields returns the fields of a message sorted by field number. GetFields returns the slice
+// owned by the descriptor, so sorting it in place would reorder the fields for every other user
+func getSortedFields(msgDesc *desc.MessageDescriptor) []*desc.FieldDescriptor {
+	fields := append([]*desc.FieldDescriptor(nil), msgDesc.GetFields()...)
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].GetNumber() < fields[j].GetNumber()
+	})
+	return fields
+}
@@ -0,0 +1,174 @@
+package protoxls
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// formatLuaName formats a name as a Lua table key, quoting reserved words
+func formatLuaName(name string) string {
+	if luaKeywords[name] {
+		return fmt.Sprintf("[%s]", quoteLuaString(name))
+	}
+	return name
+}
+
+// quoteLuaString quotes a string as a Lua literal. Only escapes understood by Lua 5.1 and later are
+// used, and the literal never spans lines, so it can be re-indented and split into chunks safely
+func quoteLuaString(s string) string {
+	var result strings.Builder
+	result.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '"':
+			result.WriteString(`\"`)
+		case '\\':
+			result.WriteString(`\\`)
+		case '\n':
+			result.WriteString(`\n`)
+		case '\r':
+			result.WriteString(`\r`)
+		case '\t':
+			result.WriteString(`\t`)
+		default:
+			if c < 0x20 || c == 0x7f {
+				// Decimal escapes are padded so a following digit is not taken as part of the escape
+				result.WriteString(fmt.Sprintf(`\%03d`, c))
+			} else {
+				result.WriteByte(c)
+			}
+		}
+	}
+	result.WriteByte('"')
+	return result.String()
+}
+
+// quotePhpString quotes a string as a single-quoted PHP literal, in which only backslashes and
+// single quotes are escaped and every other byte, including newlines, is taken literally
+func quotePhpString(s string) string {
+	escaped := strings.ReplaceAll(s, "\\", "\\\\")
+	escaped = strings.ReplaceAll(escaped, "'", "\\'")
+	return "'" + escaped + "'"
+}
+
+// quoteJSONString quotes a string as a JSON string literal
+func quoteJSONString(s string) string {
+	// Marshaling a string never fails
+	quoted, _ := json.Marshal(s)
+	return string(quoted)
+}
+
+// quoteCSharpString quotes a string as a regular C# string literal, which accepts every escape used in JSON
+func quoteCSharpString(s string) string {
+	return quoteJSONString(s)
+}
+
+// quotePythonString quotes a string as a Python 3 str literal. Valid UTF-8 is kept as is, control
+// characters are escaped and invalid bytes are replaced, as str only holds code points
+func quotePythonString(s string) string {
+	var result strings.Builder
+	result.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			result.WriteString(`\"`)
+		case '\\':
+			result.WriteString(`\\`)
+		case '\n':
+			result.WriteString(`\n`)
+		case '\r':
+			result.WriteString(`\r`)
+		case '\t':
+			result.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				result.WriteString(fmt.Sprintf(`\x%02x`, r))
+			} else {
+				result.WriteRune(r)
+			}
+		}
+	}
+	result.WriteByte('"')
+	return result.String()
+}
+
+// quotePythonBytes quotes a bytes value as a Python bytes literal, escaping every byte outside printable ASCII
+func quotePythonBytes(data []byte) string {
+	var result strings.Builder
+	result.WriteString(`b"`)
+	for _, c := range data {
+		switch c {
+		case '"':
+			result.WriteString(`\"`)
+		case '\\':
+			result.WriteString(`\\`)
+		case '\n':
+			result.WriteString(`\n`)
+		case '\r':
+			result.WriteString(`\r`)
+		case '\t':
+			result.WriteString(`\t`)
+		default:
+			if c < 0x20 || c >= 0x7f {
+				result.WriteString(fmt.Sprintf(`\x%02x`, c))
+			} else {
+				result.WriteByte(c)
+			}
+		}
+	}
+	result.WriteByte('"')
+	return result.String()
+}
+
+// quoteTextString quotes a string as a protobuf text format literal. Valid UTF-8 is kept as is,
+// control characters and invalid bytes are written as octal escapes
+func quoteTextString(s string) string {
+	return quoteText(s, false)
+}
+
+// quoteTextBytes quotes a bytes value as a protobuf text format literal, escaping every byte outside
+// printable ASCII, as protoc does
+func quoteTextBytes(data []byte) string {
+	return quoteText(string(data), true)
+}
+
+// quoteText quotes a string as a protobuf text format literal, optionally escaping all non-ASCII bytes
+func quoteText(s string, asciiOnly bool) string {
+	var result strings.Builder
+	result.WriteByte('"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch c {
+		case '"':
+			result.WriteString(`\"`)
+		case '\\':
+			result.WriteString(`\\`)
+		case '\n':
+			result.WriteString(`\n`)
+		case '\r':
+			result.WriteString(`\r`)
+		case '\t':
+			result.WriteString(`\t`)
+		default:
+			if c >= utf8.RuneSelf && !asciiOnly {
+				if r, size := utf8.DecodeRuneInString(s[i:]); r != utf8.RuneError || size > 1 {
+					result.WriteString(s[i : i+size])
+					i += size
+					continue
+				}
+			}
+			if c < 0x20 || c >= 0x7f {
+				// Octal escapes are always three digits, so a following digit is not taken as part of them
+				result.WriteString(fmt.Sprintf(`\%03o`, c))
+			} else {
+				result.WriteByte(c)
+			}
+		}
+		i++
+	}
+	result.WriteByte('"')
+	return result.String()
+}
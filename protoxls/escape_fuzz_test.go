@@ -0,0 +1,60 @@
+//go:build go1.18
+// +build go1.18
+
+package protoxls
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FuzzExportStrings exports a row holding the fuzzed string in every string field and its key,
+// and checks that the JSON and YAML outputs decode back to it and that the Lua output stays on one line
+func FuzzExportStrings(f *testing.F) {
+	for _, tc := range escapeCases {
+		f.Add(tc.in)
+	}
+	f.Fuzz(func(t *testing.T, value string) {
+		store := newEscapeStore(t, []string{value}, true)
+		key := store.GetAllKeys()[0].String()
+
+		data := exportEscapeStore(t, store, func(outputDir string) Exporter {
+			return &JsonExporter{OutputDir: outputDir}
+		}, ".json")
+		var jsonTable map[string]escapeRow
+		if err := json.Unmarshal(data, &jsonTable); err != nil {
+			t.Fatalf("JSON output for %q does not parse: %v\n%s", value, err, data)
+		}
+		if row, want := jsonTable[jsonCoerced(key)], wantEscapeRow(value, jsonCoerced); len(jsonTable) != 1 || !reflect.DeepEqual(row, want) {
+			t.Errorf("JSON output for %q decodes to %+v, want %+v under key %q", value, jsonTable, want, jsonCoerced(key))
+		}
+
+		data = exportEscapeStore(t, store, func(outputDir string) Exporter {
+			return &YamlExporter{OutputDir: outputDir}
+		}, ".yaml")
+		var yamlTable map[string]escapeRow
+		if err := yaml.Unmarshal(data, &yamlTable); err != nil {
+			t.Fatalf("YAML output for %q does not parse: %v\n%s", value, err, data)
+		}
+		identity := func(s string) string { return s }
+		if row, want := yamlTable[key], wantEscapeRow(value, identity); len(yamlTable) != 1 || !reflect.DeepEqual(row, want) {
+			t.Errorf("YAML output for %q decodes to %+v, want %+v under key %q", value, yamlTable, want, key)
+		}
+
+		// Every string of the row is a single literal, so the row takes as many lines as for a plain value
+		data = exportEscapeStore(t, store, func(outputDir string) Exporter {
+			return &LuaExporter{OutputDir: outputDir}
+		}, ".lua")
+		plain := exportEscapeStore(t, newEscapeStore(t, []string{"x"}, true), func(outputDir string) Exporter {
+			return &LuaExporter{OutputDir: outputDir}
+		}, ".lua")
+		if bytes.Count(data, []byte("\n")) != bytes.Count(plain, []byte("\n")) || strings.Contains(string(data), "\r") {
+			t.Errorf("Lua output for %q spans extra lines:\n%s", value, data)
+		}
+	})
+}
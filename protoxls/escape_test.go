@@ -0,0 +1,516 @@
+package protoxls
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/dynamic"
+	"gopkg.in/yaml.v3"
+)
+
+// escapeCase is a tricky string together with the literals each exporter is expected to write for it
+type escapeCase struct {
+	name string
+	in   string
+	lua  string
+	php  string
+}
+
+var escapeCases = []escapeCase{
+	{"empty", "", `""`, `''`},
+	{"plain", "hello", `"hello"`, `'hello'`},
+	{"double quote", `say "hi"`, `"say \"hi\""`, `'say "hi"'`},
+	{"single quote", "it's", `"it's"`, `'it\'s'`},
+	{"backslash", `C:\path\`, `"C:\\path\\"`, `'C:\\path\\'`},
+	{"escaped quote", `\'`, `"\\'"`, `'\\\''`},
+	{"newline", "a\nb", `"a\nb"`, "'a\nb'"},
+	{"carriage return", "a\r\nb", `"a\r\nb"`, "'a\r\nb'"},
+	{"leading newline", "\n0", `"\n0"`, "'\n0'"},
+	{"tab", "a\tb", `"a\tb"`, "'a\tb'"},
+	{"nul", "a\x00b", `"a\000b"`, "'a\x00b'"},
+	{"nul before digit", "\x001", `"\0001"`, "'\x001'"},
+	{"control bytes", "\x01\x1b\x1f\x7f", `"\001\027\031\127"`, "'\x01\x1b\x1f\x7f'"},
+	{"invalid utf8", "\xff\xfe", "\"\xff\xfe\"", "'\xff\xfe'"},
+	{"truncated utf8", "\xe4\xb8", "\"\xe4\xb8\"", "'\xe4\xb8'"},
+	{"unicode", "英雄", `"英雄"`, `'英雄'`},
+	{"long bracket close", "]]", `"]]"`, `']]'`},
+	{"long bracket open", "[[x]]", `"[[x]]"`, `'[[x]]'`},
+	{"leveled long bracket", "]==]", `"]==]"`, `']==]'`},
+	{"php close tag", "?>", `"?>"`, `'?>'`},
+	{"php open tag", "<?php echo 1; ?>", `"<?php echo 1; ?>"`, `'<?php echo 1; ?>'`},
+	{"php interpolation", "$name {$x}", `"$name {$x}"`, `'$name {$x}'`},
+	{"lua comment", "--[[", `"--[["`, `'--[['`},
+	{"keyword", "end", `"end"`, `'end'`},
+	{"numeric", "123", `"123"`, `'123'`},
+	{"leading zero", "007", `"007"`, `'007'`},
+	{"negative", "-1", `"-1"`, `'-1'`},
+	{"float", "1e10", `"1e10"`, `'1e10'`},
+}
+
+func TestQuoteLuaString(t *testing.T) {
+	for _, tc := range escapeCases {
+		if got := quoteLuaString(tc.in); got != tc.lua {
+			t.Errorf("%s: quoteLuaString(%q) = %s, want %s", tc.name, tc.in, got, tc.lua)
+		}
+	}
+}
+
+func TestQuotePhpString(t *testing.T) {
+	for _, tc := range escapeCases {
+		if got := quotePhpString(tc.in); got != tc.php {
+			t.Errorf("%s: quotePhpString(%q) = %s, want %s", tc.name, tc.in, got, tc.php)
+		}
+	}
+}
+
+func TestQuoteJSONString(t *testing.T) {
+	for _, tc := range escapeCases {
+		quoted := quoteJSONString(tc.in)
+		var got string
+		if err := json.Unmarshal([]byte(quoted), &got); err != nil {
+			t.Errorf("%s: quoteJSONString(%q) = %s does not parse: %v", tc.name, tc.in, quoted, err)
+			continue
+		}
+		// JSON strings hold code points only, so each invalid byte comes back as U+FFFD
+		if want := jsonCoerced(tc.in); got != want {
+			t.Errorf("%s: quoteJSONString(%q) = %s round-trips to %q", tc.name, tc.in, quoted, got)
+		}
+		if strings.ContainsAny(quoted, "\n\r\x00") {
+			t.Errorf("%s: quoteJSONString(%q) = %q contains a raw control character", tc.name, tc.in, quoted)
+		}
+	}
+}
+
+func TestFormatLuaName(t *testing.T) {
+	for keyword := range luaKeywords {
+		want := `["` + keyword + `"]`
+		if got := formatLuaName(keyword); got != want {
+			t.Errorf("formatLuaName(%q) = %s, want %s", keyword, got, want)
+		}
+	}
+	for _, name := range []string{"id", "name", "End", "ends", "_end", "goto_"} {
+		if got := formatLuaName(name); got != name {
+			t.Errorf("formatLuaName(%q) = %s, want %s", name, got, name)
+		}
+	}
+}
+
+func TestFormatStoreKeys(t *testing.T) {
+	le := &LuaExporter{}
+	pe := &PhpExporter{}
+	cases := []struct {
+		key StoreKey
+		lua string
+		php string
+	}{
+		{StoreKey{KeyType: KeyTypeInteger, IntegerValue: 123}, `123`, `'123'`},
+		{StoreKey{KeyType: KeyTypeInteger, IntegerValue: -7}, `-7`, `'-7'`},
+		// Numeric-looking string keys stay strings, so they never collide with integer keys
+		{StoreKey{KeyType: KeyTypeString, StringValue: "123"}, `"123"`, `'123'`},
+		{StoreKey{KeyType: KeyTypeString, StringValue: "007"}, `"007"`, `'007'`},
+		{StoreKey{KeyType: KeyTypeString, StringValue: "end"}, `"end"`, `'end'`},
+		{StoreKey{KeyType: KeyTypeString, StringValue: "]]"}, `"]]"`, `']]'`},
+		{StoreKey{KeyType: KeyTypeString, StringValue: "it's\n"}, `"it's\n"`, "'it\\'s\n'"},
+	}
+	for _, tc := range cases {
+		if got := le.formatLuaKey(tc.key); got != tc.lua {
+			t.Errorf("formatLuaKey(%+v) = %s, want %s", tc.key, got, tc.lua)
+		}
+		if got := pe.formatPhpKey(tc.key); got != tc.php {
+			t.Errorf("formatPhpKey(%+v) = %s, want %s", tc.key, got, tc.php)
+		}
+	}
+}
+
+// jsonCoerced returns s with every invalid byte replaced by U+FFFD, which is how encoding/json writes invalid UTF-8
+func jsonCoerced(s string) string {
+	var result strings.Builder
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			result.WriteRune(utf8.RuneError)
+		} else {
+			result.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	return result.String()
+}
+
+// escapeTestProto is the schema of the exporter tests. The end field is named after a Lua keyword
+const escapeTestProto = `syntax = "proto3";
+package escape;
+
+message Note {
+    string text = 1;
+}
+
+message Row {
+    string key = 1;
+    string text = 2;
+    repeated string tags = 3;
+    Note note = 4;
+    string end = 5;
+}
+`
+
+// escapeRow is a Row as decoded from the JSON and YAML outputs
+type escapeRow struct {
+	Key  string   `json:"key" yaml:"key"`
+	Text string   `json:"text" yaml:"text"`
+	Tags []string `json:"tags" yaml:"tags"`
+	Note struct {
+		Text string `json:"text" yaml:"text"`
+	} `json:"note" yaml:"note"`
+	End string `json:"end" yaml:"end"`
+}
+
+// wantEscapeRow returns the row expected for a value, after applying coerce to every string
+func wantEscapeRow(value string, coerce func(string) string) escapeRow {
+	row := escapeRow{Key: coerce(value), Text: coerce(value), Tags: []string{coerce(value), "t"}, End: coerce(value)}
+	row.Note.Text = coerce(value)
+	return row
+}
+
+// newEscapeStore returns a store holding a Row per value, with the value in every string field.
+// The rows are keyed by the key field unless keyed is false
+func newEscapeStore(t testing.TB, values []string, keyed bool) *TableStore {
+	parser := protoparse.Parser{Accessor: protoparse.FileContentsFromMap(map[string]string{"escape.proto": escapeTestProto})}
+	files, err := parser.ParseFiles("escape.proto")
+	if err != nil {
+		t.Fatalf("failed to parse test schema: %v", err)
+	}
+	msgDesc := files[0].FindMessage("escape.Row")
+	noteDesc := files[0].FindMessage("escape.Note")
+
+	store := NewTableStore(msgDesc)
+	for _, value := range values {
+		note := dynamic.NewMessage(noteDesc)
+		note.SetFieldByName("text", value)
+		row := dynamic.NewMessage(msgDesc)
+		row.SetFieldByName("key", value)
+		row.SetFieldByName("text", value)
+		row.SetFieldByName("tags", []string{value, "t"})
+		row.SetFieldByName("note", note)
+		row.SetFieldByName("end", value)
+		store.AddMessage(row)
+	}
+	if keyed {
+		if err := store.BuildHierarchicalStore([]string{"key"}); err != nil {
+			t.Fatalf("failed to build store: %v", err)
+		}
+	}
+	return store
+}
+
+// exportEscapeStore runs an exporter writing to a temporary directory and returns the file written for the store
+func exportEscapeStore(t testing.TB, store *TableStore, newExporter func(outputDir string) Exporter, extension string) []byte {
+	outputDir, err := ioutil.TempDir("", "protoxls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(outputDir)
+
+	if err := newExporter(outputDir).ExportResult(store); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(outputDir, GetTableName(store)+extension))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// escapeValues returns the inputs of the escape cases, which are all distinct keys
+func escapeValues() []string {
+	values := make([]string, 0, len(escapeCases))
+	for _, tc := range escapeCases {
+		values = append(values, tc.in)
+	}
+	return values
+}
+
+// decodeJSONObject decodes a JSON object into its keys in order and its rows
+func decodeJSONObject(t testing.TB, data []byte) ([]string, []escapeRow) {
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		t.Fatalf("output does not start an object: %v %v\n%s", token, err, data)
+	}
+	var keys []string
+	var rows []escapeRow
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			t.Fatalf("failed to read key: %v\n%s", err, data)
+		}
+		var row escapeRow
+		if err := decoder.Decode(&row); err != nil {
+			t.Fatalf("failed to decode row %q: %v\n%s", token, err, data)
+		}
+		keys = append(keys, token.(string))
+		rows = append(rows, row)
+	}
+	if token, err := decoder.Token(); err != nil || token != json.Delim('}') {
+		t.Fatalf("output does not end the object: %v %v\n%s", token, err, data)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		t.Fatalf("trailing data after the object: %v\n%s", err, data)
+	}
+	return keys, rows
+}
+
+func TestJsonExporterEscaping(t *testing.T) {
+	values := escapeValues()
+	for _, compact := range []bool{false, true} {
+		newExporter := func(outputDir string) Exporter {
+			return &JsonExporter{OutputDir: outputDir, CompactFormat: compact}
+		}
+
+		// Keyed tables write the keys themselves, and every row through OrderedMap.MarshalJSON
+		store := newEscapeStore(t, values, true)
+		keys, rows := decodeJSONObject(t, exportEscapeStore(t, store, newExporter, ".json"))
+		storeKeys := store.GetAllKeys()
+		if len(keys) != len(storeKeys) {
+			t.Fatalf("compact=%v: decoded %d keys, want %d", compact, len(keys), len(storeKeys))
+		}
+		for i, storeKey := range storeKeys {
+			if want := jsonCoerced(storeKey.String()); keys[i] != want {
+				t.Errorf("compact=%v: key %d decodes to %q, want %q", compact, i, keys[i], want)
+			}
+			if want := wantEscapeRow(values[i], jsonCoerced); !reflect.DeepEqual(rows[i], want) {
+				t.Errorf("compact=%v: row %q decodes to %+v, want %+v", compact, values[i], rows[i], want)
+			}
+		}
+
+		// Tables without keys are written as an array
+		var list []escapeRow
+		data := exportEscapeStore(t, newEscapeStore(t, values, false), newExporter, ".json")
+		if err := json.Unmarshal(data, &list); err != nil {
+			t.Fatalf("compact=%v: array output does not parse: %v\n%s", compact, err, data)
+		}
+		if len(list) != len(values) {
+			t.Fatalf("compact=%v: decoded %d rows, want %d", compact, len(list), len(values))
+		}
+		for i, value := range values {
+			if want := wantEscapeRow(value, jsonCoerced); !reflect.DeepEqual(list[i], want) {
+				t.Errorf("compact=%v: row %q decodes to %+v, want %+v", compact, value, list[i], want)
+			}
+		}
+	}
+}
+
+func TestYamlExporterEscaping(t *testing.T) {
+	values := escapeValues()
+	newExporter := func(outputDir string) Exporter {
+		return &YamlExporter{OutputDir: outputDir}
+	}
+	identity := func(s string) string { return s }
+
+	// YAML writes invalid UTF-8 as !!binary, so every string reads back exactly
+	store := newEscapeStore(t, values, true)
+	data := exportEscapeStore(t, store, newExporter, ".yaml")
+	var table map[string]escapeRow
+	if err := yaml.Unmarshal(data, &table); err != nil {
+		t.Fatalf("keyed output does not parse: %v\n%s", err, data)
+	}
+	if len(table) != len(values) {
+		t.Fatalf("decoded %d keys, want %d\n%s", len(table), len(values), data)
+	}
+	for i, storeKey := range store.GetAllKeys() {
+		row, ok := table[storeKey.String()]
+		if !ok {
+			t.Errorf("key %q is missing", storeKey.String())
+			continue
+		}
+		if want := wantEscapeRow(values[i], identity); !reflect.DeepEqual(row, want) {
+			t.Errorf("row %q decodes to %+v, want %+v", values[i], row, want)
+		}
+	}
+
+	var list []escapeRow
+	data = exportEscapeStore(t, newEscapeStore(t, values, false), newExporter, ".yaml")
+	if err := yaml.Unmarshal(data, &list); err != nil {
+		t.Fatalf("array output does not parse: %v\n%s", err, data)
+	}
+	if len(list) != len(values) {
+		t.Fatalf("decoded %d rows, want %d", len(list), len(values))
+	}
+	for i, value := range values {
+		if want := wantEscapeRow(value, identity); !reflect.DeepEqual(list[i], want) {
+			t.Errorf("row %q decodes to %+v, want %+v", value, list[i], want)
+		}
+	}
+}
+
+// escapeRowValues are the values of the rows whose Lua and PHP output is checked in full
+var escapeRowValues = []string{"say \"hi\"", "it's \\", "a\r\nb\x00", "\xff]]", "?>", "end", "007"}
+
+func TestLuaExporterEscaping(t *testing.T) {
+	store := newEscapeStore(t, escapeRowValues, true)
+	got := string(exportEscapeStore(t, store, func(outputDir string) Exporter {
+		return &LuaExporter{OutputDir: outputDir}
+	}, ".lua"))
+	want := `row = {
+    ["say \"hi\""] = {
+    key = "say \"hi\"",
+    text = "say \"hi\"",
+    tags = {"say \"hi\"", "t"},
+    note = {
+        text = "say \"hi\""
+    },
+    ["end"] = "say \"hi\""
+},
+    ["it's \\"] = {
+    key = "it's \\",
+    text = "it's \\",
+    tags = {"it's \\", "t"},
+    note = {
+        text = "it's \\"
+    },
+    ["end"] = "it's \\"
+},
+    ["a\r\nb\000"] = {
+    key = "a\r\nb\000",
+    text = "a\r\nb\000",
+    tags = {"a\r\nb\000", "t"},
+    note = {
+        text = "a\r\nb\000"
+    },
+    ["end"] = "a\r\nb\000"
+},
+    ["\xff]]"] = {
+    key = "\xff]]",
+    text = "\xff]]",
+    tags = {"\xff]]", "t"},
+    note = {
+        text = "\xff]]"
+    },
+    ["end"] = "\xff]]"
+},
+    ["?>"] = {
+    key = "?>",
+    text = "?>",
+    tags = {"?>", "t"},
+    note = {
+        text = "?>"
+    },
+    ["end"] = "?>"
+},
+    ["end"] = {
+    key = "end",
+    text = "end",
+    tags = {"end", "t"},
+    note = {
+        text = "end"
+    },
+    ["end"] = "end"
+},
+    [7] = {
+    key = "007",
+    text = "007",
+    tags = {"007", "t"},
+    note = {
+        text = "007"
+    },
+    ["end"] = "007"
+}
+}`
+	// Invalid UTF-8 is written as is, Lua strings are byte strings
+	want = strings.ReplaceAll(want, `\xff`, "\xff")
+	if got != want {
+		t.Errorf("Lua output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPhpExporterEscaping(t *testing.T) {
+	store := newEscapeStore(t, escapeRowValues, true)
+	got := string(exportEscapeStore(t, store, func(outputDir string) Exporter {
+		return &PhpExporter{OutputDir: outputDir}
+	}, ".php"))
+	want := `<?php
+
+$row = [
+    'say "hi"' => [
+    'key' => 'say "hi"',
+    'text' => 'say "hi"',
+    'tags' => ['say "hi"', 't'],
+    'note' => [
+        'text' => 'say "hi"'
+    ],
+    'end' => 'say "hi"'
+],
+    'it\'s \\' => [
+    'key' => 'it\'s \\',
+    'text' => 'it\'s \\',
+    'tags' => ['it\'s \\', 't'],
+    'note' => [
+        'text' => 'it\'s \\'
+    ],
+    'end' => 'it\'s \\'
+],
+    'a\r
+b\x00' => [
+    'key' => 'a\r
+b\x00',
+    'text' => 'a\r
+b\x00',
+    'tags' => ['a\r
+b\x00', 't'],
+    'note' => [
+        'text' => 'a\r
+b\x00'
+    ],
+    'end' => 'a\r
+b\x00'
+],
+    '\xff]]' => [
+    'key' => '\xff]]',
+    'text' => '\xff]]',
+    'tags' => ['\xff]]', 't'],
+    'note' => [
+        'text' => '\xff]]'
+    ],
+    'end' => '\xff]]'
+],
+    '?>' => [
+    'key' => '?>',
+    'text' => '?>',
+    'tags' => ['?>', 't'],
+    'note' => [
+        'text' => '?>'
+    ],
+    'end' => '?>'
+],
+    'end' => [
+    'key' => 'end',
+    'text' => 'end',
+    'tags' => ['end', 't'],
+    'note' => [
+        'text' => 'end'
+    ],
+    'end' => 'end'
+],
+    '7' => [
+    'key' => '007',
+    'text' => '007',
+    'tags' => ['007', 't'],
+    'note' => [
+        'text' => '007'
+    ],
+    'end' => '007'
+]
+];`
+	// Single-quoted PHP strings take every other byte literally, including line breaks and NUL
+	want = strings.NewReplacer(`\r`, "\r", `\x00`, "\x00", `\xff`, "\xff").Replace(want)
+	if got != want {
+		t.Errorf("PHP output:\n%s\nwant:\n%s", got, want)
+	}
+}
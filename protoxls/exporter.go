@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/jhump/protoreflect/desc"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -21,6 +22,11 @@ type Exporter interface {
 	ExportResult(store *TableStore) error
 }
 
+// EnumExporter is implemented by exporters that can also write the enum definitions used by the tables
+type EnumExporter interface {
+	ExportEnums(enums []*desc.EnumDescriptor) error
+}
+
 
 // GetTableName returns the preferred table name, prioritizing table option
 func GetTableName(store *TableStore) string {
@@ -42,7 +48,11 @@ func GetTableName(store *TableStore) string {
 // This centralizes the common file creation logic used by all exporters
 func CreateOutputFile(store *TableStore, outputDir, fileType string) (*os.File, error) {
 	// Generate filename based on table name and file type
-	tableName := GetTableName(store)
+	return CreateNamedOutputFile(GetTableName(store), outputDir, fileType)
+}
+
+// CreateNamedOutputFile creates an output file named after baseName instead of a table, such as the enum definitions
+func CreateNamedOutputFile(baseName, outputDir, fileType string) (*os.File, error) {
 	var extension string
 	switch strings.ToLower(fileType) {
 	case "json":
@@ -51,10 +61,20 @@ func CreateOutputFile(store *TableStore, outputDir, fileType string) (*os.File,
 		extension = ".lua"
 	case "binary", "bin":
 		extension = ".bin"
+	case "json schema":
+		extension = ".schema.json"
+	case "lua annotation":
+		extension = ".meta.lua"
+	case "typescript":
+		extension = ".ts"
+	case "typescript declaration":
+		extension = ".d.ts"
+	case "python":
+		extension = ".py"
 	default:
 		extension = "." + strings.ToLower(fileType)
 	}
-	fileName := tableName + extension
+	fileName := baseName + extension
 
 	// Use default output directory if not specified
 	if outputDir == "" {
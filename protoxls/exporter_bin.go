@@ -6,7 +6,9 @@ import (
 
 // BinExporter exports configuration data to binary format
 type BinExporter struct {
-	OutputDir string // Custom output directory, defaults to DefaultOutputDir if empty
+	OutputDir        string // Custom output directory, defaults to DefaultOutputDir if empty
+	Format           string // Layout of the file, BinFormatRaw if empty
+	EmbedDescriptors bool   // Whether containers embed the FileDescriptorSet of the table
 }
 
 // ExportResult exports configuration data to binary format
@@ -18,6 +20,15 @@ func (be *BinExporter) ExportResult(store *TableStore) error {
 	}
 	defer file.Close()
 
+	switch be.Format {
+	case BinFormatContainer:
+		return be.writeContainer(file, store)
+	case BinFormatWrapper:
+		return be.writeWrapper(file, store)
+	case BinFormatIndexed:
+		return be.writeIndexed(file, store)
+	}
+
 	// Export all data messages to binary format
 	messages := store.GetAllMessages()
 	for i, message := range messages {
@@ -0,0 +1,205 @@
+package protoxls
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+
+	"github.com/jhump/protoreflect/desc"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	// BinFormatRaw writes each row as a 4-byte big-endian length followed by the message
+	BinFormatRaw = "raw"
+	// BinFormatContainer writes the rows in a self-describing container, see writeContainer
+	BinFormatContainer = "container"
+	// BinFormatWrapper writes the rows as a message whose field 1 is the repeated row message
+	BinFormatWrapper = "wrapper"
+
+	// BinContainerMagic starts every container file
+	BinContainerMagic = "PXLS"
+	// BinContainerVersion is the version of the container layout
+	BinContainerVersion = 1
+	// BinContainerFlagDescriptors marks containers embedding a FileDescriptorSet
+	BinContainerFlagDescriptors = 1 << 0
+
+	// binWrapperRowsField is the field number of the rows in the wrapper message
+	binWrapperRowsField = 1
+)
+
+// IsValidBinFormat checks if a string is one of the binary formats
+func IsValidBinFormat(format string) bool {
+	return format == BinFormatRaw || format == BinFormatContainer || format == BinFormatWrapper || format == BinFormatIndexed
+}
+
+// writeContainer writes the rows of a table in the container layout, all integers big-endian:
+//
+//	magic        4 bytes, "PXLS"
+//	version      uint16, BinContainerVersion
+//	flags        uint16, BinContainerFlagDescriptors if descriptors are embedded
+//	table name   uint16 length + UTF-8
+//	message type uint16 length + UTF-8 full name of the row message
+//	keys         uint16 count, then uint16 length + UTF-8 name of each (keys) field
+//	fingerprint  32 bytes, SHA-256 of the schema, see schemaFingerprint
+//	row count    uint32
+//	descriptors  uint32 length + serialized FileDescriptorSet, length 0 if not embedded
+//	rows         row count times uint32 length + serialized row message
+//	checksum     uint32, CRC-32 (IEEE) of all preceding bytes
+func (be *BinExporter) writeContainer(writer io.Writer, store *TableStore) error {
+	msgDesc := store.GetMessageDescriptor()
+	keyFields, err := getTableKeyFields(store)
+	if err != nil {
+		return err
+	}
+	fingerprint, err := schemaFingerprint(msgDesc)
+	if err != nil {
+		return err
+	}
+
+	var flags uint16
+	var descriptorBytes []byte
+	if be.EmbedDescriptors {
+		flags |= BinContainerFlagDescriptors
+		descriptorBytes, err = marshalFileDescriptorSet(BuildFileDescriptorSet([]*desc.FileDescriptor{msgDesc.GetFile()}, false))
+		if err != nil {
+			return err
+		}
+	}
+
+	checksum := crc32.NewIEEE()
+	buffered := bufio.NewWriter(io.MultiWriter(writer, checksum))
+	container := &binContainerWriter{writer: buffered}
+
+	container.write([]byte(BinContainerMagic))
+	container.writeUint16(BinContainerVersion)
+	container.writeUint16(flags)
+	container.writeString("table name length", GetTableName(store))
+	container.writeString("message name length", msgDesc.GetFullyQualifiedName())
+	container.writeLength16("key field count", len(keyFields))
+	for _, keyField := range keyFields {
+		container.writeString("key field name length", keyField.GetName())
+	}
+	container.write(fingerprint[:])
+
+	messages := store.GetAllMessages()
+	container.writeLength32("row count", len(messages))
+	container.writeLength32("descriptor set size", len(descriptorBytes))
+	container.write(descriptorBytes)
+	for i, message := range messages {
+		messageBytes, err := message.Marshal()
+		if err != nil {
+			return fmt.Errorf("failed to marshal message %d: %v", i, err)
+		}
+		container.writeLength32(fmt.Sprintf("size of message %d", i), len(messageBytes))
+		container.write(messageBytes)
+	}
+
+	if container.err != nil {
+		return fmt.Errorf("failed to write container: %v", container.err)
+	}
+	if err := buffered.Flush(); err != nil {
+		return fmt.Errorf("failed to write container: %v", err)
+	}
+
+	// The checksum itself is written past the hash
+	if err := binary.Write(writer, binary.BigEndian, checksum.Sum32()); err != nil {
+		return fmt.Errorf("failed to write container checksum: %v", err)
+	}
+	return nil
+}
+
+// writeWrapper writes the rows as the encoding of a message holding them in a repeated field:
+//
+//	message HeroConfigList {
+//	    repeated HeroConfig rows = 1;
+//	}
+func (be *BinExporter) writeWrapper(writer io.Writer, store *TableStore) error {
+	buffered := bufio.NewWriter(writer)
+	for i, message := range store.GetAllMessages() {
+		messageBytes, err := message.Marshal()
+		if err != nil {
+			return fmt.Errorf("failed to marshal message %d: %v", i, err)
+		}
+
+		var field []byte
+		field = protowire.AppendTag(field, binWrapperRowsField, protowire.BytesType)
+		field = protowire.AppendBytes(field, messageBytes)
+		if _, err := buffered.Write(field); err != nil {
+			return fmt.Errorf("failed to write message %d: %v", i, err)
+		}
+	}
+
+	if err := buffered.Flush(); err != nil {
+		return fmt.Errorf("failed to write wrapper message: %v", err)
+	}
+	return nil
+}
+
+// binContainerWriter writes big-endian fields of the container and indexed layouts, keeping the first error
+type binContainerWriter struct {
+	writer io.Writer
+	err    error
+}
+
+// write writes bytes unless an earlier write failed
+func (cw *binContainerWriter) write(data []byte) {
+	if cw.err == nil {
+		_, cw.err = cw.writer.Write(data)
+	}
+}
+
+// writeUint16 writes a big-endian uint16
+func (cw *binContainerWriter) writeUint16(value uint16) {
+	var data [2]byte
+	binary.BigEndian.PutUint16(data[:], value)
+	cw.write(data[:])
+}
+
+// writeUint32 writes a big-endian uint32
+func (cw *binContainerWriter) writeUint32(value uint32) {
+	var data [4]byte
+	binary.BigEndian.PutUint32(data[:], value)
+	cw.write(data[:])
+}
+
+// writeUint64 writes a big-endian uint64
+func (cw *binContainerWriter) writeUint64(value uint64) {
+	var data [8]byte
+	binary.BigEndian.PutUint64(data[:], value)
+	cw.write(data[:])
+}
+
+// writeLength16 writes a length or count as a uint16, failing instead of truncating values that do not fit
+func (cw *binContainerWriter) writeLength16(what string, length int) {
+	if length > math.MaxUint16 {
+		cw.fail(what, length, math.MaxUint16)
+		return
+	}
+	cw.writeUint16(uint16(length))
+}
+
+// writeLength32 writes a length or count as a uint32, failing instead of truncating values that do not fit
+func (cw *binContainerWriter) writeLength32(what string, length int) {
+	if uint64(length) > math.MaxUint32 {
+		cw.fail(what, length, math.MaxUint32)
+		return
+	}
+	cw.writeUint32(uint32(length))
+}
+
+// fail records a length exceeding the limit of its field unless an earlier write failed
+func (cw *binContainerWriter) fail(what string, length int, limit uint64) {
+	if cw.err == nil {
+		cw.err = fmt.Errorf("%s %d exceeds the limit of %d", what, length, limit)
+	}
+}
+
+// writeString writes a string with a uint16 length prefix, failing for strings longer than 65535 bytes
+func (cw *binContainerWriter) writeString(what string, value string) {
+	cw.writeLength16(what, len(value))
+	cw.write([]byte(value))
+}
@@ -0,0 +1,334 @@
+package protoxls
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jhump/protoreflect/dynamic"
+)
+
+const (
+	// BinFormatIndexed writes the rows after an index sorted by key, see writeIndexed
+	BinFormatIndexed = "indexed"
+
+	// BinIndexedMagic starts every indexed binary file
+	BinIndexedMagic = "PXLI"
+	// BinIndexedVersion is the version of the indexed layout
+	BinIndexedVersion = 1
+
+	// binIndexedHeaderSize is the size of the fixed part of the header
+	binIndexedHeaderSize = 32
+	// binIndexedKeySize is the size of one key in an index entry: a kind byte and 8 bytes of value
+	binIndexedKeySize = 9
+	// binIndexedRowSize is the size of the row offset and length ending an index entry
+	binIndexedRowSize = 12
+)
+
+// binIndexEntry is a row with its key path, one key per (keys) field
+type binIndexEntry struct {
+	keys    []StoreKey
+	message *dynamic.Message
+}
+
+// compareStoreKeys orders integer keys before string keys, integers by value and strings bytewise
+func compareStoreKeys(a, b StoreKey) int {
+	switch {
+	case a.KeyType != b.KeyType:
+		if a.KeyType == KeyTypeInteger {
+			return -1
+		}
+		return 1
+	case a.KeyType == KeyTypeInteger:
+		if a.IntegerValue < b.IntegerValue {
+			return -1
+		}
+		if a.IntegerValue > b.IntegerValue {
+			return 1
+		}
+		return 0
+	default:
+		return strings.Compare(a.StringValue, b.StringValue)
+	}
+}
+
+// compareKeyPaths compares key paths level by level
+func compareKeyPaths(a, b []StoreKey) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if result := compareStoreKeys(a[i], b[i]); result != 0 {
+			return result
+		}
+	}
+	return len(a) - len(b)
+}
+
+// collectIndexEntries walks the (keys) hierarchy of a store, returning every row with its key path
+func collectIndexEntries(store *TableStore, path []StoreKey, entries []binIndexEntry) []binIndexEntry {
+	if !store.HasChildStores() {
+		for _, message := range store.GetAllMessages() {
+			entries = append(entries, binIndexEntry{keys: path, message: message})
+		}
+		return entries
+	}
+
+	for _, key := range store.GetAllKeys() {
+		if childStore := store.GetChildStore(key); childStore != nil {
+			childPath := append(append([]StoreKey{}, path...), key)
+			entries = collectIndexEntries(childStore, childPath, entries)
+		}
+	}
+	return entries
+}
+
+// writeIndexed writes the rows of a table in the indexed layout, all integers big-endian and all
+// offsets from the start of the file:
+//
+//	magic         4 bytes, "PXLI"
+//	version       uint16, BinIndexedVersion
+//	key levels    uint16, number of (keys) fields
+//	row count     uint32
+//	reserved      uint32, 0
+//	index offset  uint64
+//	data offset   uint64
+//	names         uint16 length + UTF-8 of the table name, the full name of the row message
+//	              and the name of each (keys) field
+//	index         row count entries sorted by key path, rows with equal keys in sheet order:
+//	              key levels times kind uint8 (1 integer, 2 string) + 8 bytes holding an int64,
+//	              or the uint32 offset into the string pool and uint32 length of a string,
+//	              then the uint64 offset and uint32 length of the row message
+//	string pool   UTF-8 bytes of the string keys
+//	data          serialized row messages in index order
+func (be *BinExporter) writeIndexed(writer io.Writer, store *TableStore) error {
+	keyFieldNames := store.GetKeyFieldNames()
+	entries := collectIndexEntries(store, nil, nil)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return compareKeyPaths(entries[i].keys, entries[j].keys) < 0
+	})
+
+	names := []string{GetTableName(store), store.GetMessageDescriptor().GetFullyQualifiedName()}
+	names = append(names, keyFieldNames...)
+	namesSize := 0
+	for _, name := range names {
+		namesSize += 2 + len(name)
+	}
+
+	// Rows and string keys are serialized first, as the index holds their offsets
+	rows := make([][]byte, len(entries))
+	var pool []byte
+	poolOffsets := make(map[string]int)
+	for i, entry := range entries {
+		messageBytes, err := entry.message.Marshal()
+		if err != nil {
+			return fmt.Errorf("failed to marshal message %d: %v", i, err)
+		}
+		rows[i] = messageBytes
+
+		for _, key := range entry.keys {
+			if _, exists := poolOffsets[key.StringValue]; key.KeyType == KeyTypeString && !exists {
+				poolOffsets[key.StringValue] = len(pool)
+				pool = append(pool, key.StringValue...)
+			}
+		}
+	}
+
+	entrySize := len(keyFieldNames)*binIndexedKeySize + binIndexedRowSize
+	indexOffset := uint64(binIndexedHeaderSize + namesSize)
+	dataOffset := indexOffset + uint64(len(entries)*entrySize) + uint64(len(pool))
+
+	buffered := bufio.NewWriter(writer)
+	out := &binContainerWriter{writer: buffered}
+	out.write([]byte(BinIndexedMagic))
+	out.writeUint16(BinIndexedVersion)
+	out.writeLength16("key level count", len(keyFieldNames))
+	out.writeLength32("row count", len(entries))
+	out.writeUint32(0)
+	out.writeUint64(indexOffset)
+	out.writeUint64(dataOffset)
+	for _, name := range names {
+		out.writeString("name length", name)
+	}
+
+	rowOffset := dataOffset
+	for i, entry := range entries {
+		for _, key := range entry.keys {
+			out.write([]byte{byte(key.KeyType)})
+			if key.KeyType == KeyTypeInteger {
+				out.writeUint64(uint64(key.IntegerValue))
+			} else {
+				out.writeLength32("string pool offset", poolOffsets[key.StringValue])
+				out.writeLength32("string key length", len(key.StringValue))
+			}
+		}
+		out.writeUint64(rowOffset)
+		out.writeLength32(fmt.Sprintf("size of message %d", i), len(rows[i]))
+		rowOffset += uint64(len(rows[i]))
+	}
+	out.write(pool)
+	for _, row := range rows {
+		out.write(row)
+	}
+
+	if out.err != nil {
+		return fmt.Errorf("failed to write indexed binary: %v", out.err)
+	}
+	if err := buffered.Flush(); err != nil {
+		return fmt.Errorf("failed to write indexed binary: %v", err)
+	}
+	return nil
+}
+
+// IndexedBinReader looks up rows of an indexed binary file by key with a binary search over the index,
+// without decoding the whole table. It reads the file contents in place, so they may be memory-mapped
+type IndexedBinReader struct {
+	data          []byte
+	tableName     string
+	messageType   string
+	keyFieldNames []string
+	rowCount      int
+	entrySize     int
+	indexOffset   int
+	poolOffset    int
+	dataOffset    int
+}
+
+// NewIndexedBinReader checks the header of an indexed binary file and returns a reader over its contents
+func NewIndexedBinReader(data []byte) (*IndexedBinReader, error) {
+	if len(data) < binIndexedHeaderSize || string(data[:4]) != BinIndexedMagic {
+		return nil, fmt.Errorf("not an indexed binary file")
+	}
+	if version := binary.BigEndian.Uint16(data[4:]); version != BinIndexedVersion {
+		return nil, fmt.Errorf("unsupported indexed binary version %d", version)
+	}
+
+	keyLevels := int(binary.BigEndian.Uint16(data[6:]))
+	reader := &IndexedBinReader{
+		data:      data,
+		rowCount:  int(binary.BigEndian.Uint32(data[8:])),
+		entrySize: keyLevels*binIndexedKeySize + binIndexedRowSize,
+	}
+	indexOffset := binary.BigEndian.Uint64(data[16:])
+	dataOffset := binary.BigEndian.Uint64(data[24:])
+
+	// Table name, message type and key field names
+	position := binIndexedHeaderSize
+	names := make([]string, 0, 2+keyLevels)
+	for i := 0; i < 2+keyLevels; i++ {
+		if position+2 > len(data) {
+			return nil, fmt.Errorf("truncated indexed binary header")
+		}
+		length := int(binary.BigEndian.Uint16(data[position:]))
+		if position+2+length > len(data) {
+			return nil, fmt.Errorf("truncated indexed binary header")
+		}
+		names = append(names, string(data[position+2:position+2+length]))
+		position += 2 + length
+	}
+	reader.tableName, reader.messageType, reader.keyFieldNames = names[0], names[1], names[2:]
+
+	poolOffset := indexOffset + uint64(reader.rowCount)*uint64(reader.entrySize)
+	if indexOffset != uint64(position) || poolOffset > dataOffset || dataOffset > uint64(len(data)) {
+		return nil, fmt.Errorf("invalid indexed binary section offsets")
+	}
+	reader.indexOffset, reader.poolOffset, reader.dataOffset = int(indexOffset), int(poolOffset), int(dataOffset)
+	return reader, nil
+}
+
+// TableName returns the name of the table
+func (r *IndexedBinReader) TableName() string {
+	return r.tableName
+}
+
+// MessageType returns the full name of the row message
+func (r *IndexedBinReader) MessageType() string {
+	return r.messageType
+}
+
+// KeyFieldNames returns the names of the (keys) fields the index is sorted by
+func (r *IndexedBinReader) KeyFieldNames() []string {
+	return r.keyFieldNames
+}
+
+// Len returns the number of rows
+func (r *IndexedBinReader) Len() int {
+	return r.rowCount
+}
+
+// Row returns the serialized message of the i-th row in index order, or false if it is out of range
+func (r *IndexedBinReader) Row(i int) ([]byte, bool) {
+	if i < 0 || i >= r.rowCount {
+		return nil, false
+	}
+	entry := r.entry(i)
+	rowOffset := binary.BigEndian.Uint64(entry[len(entry)-binIndexedRowSize:])
+	rowLength := uint64(binary.BigEndian.Uint32(entry[len(entry)-4:]))
+	if rowOffset < uint64(r.dataOffset) || rowOffset > uint64(len(r.data)) || rowLength > uint64(len(r.data))-rowOffset {
+		return nil, false
+	}
+	return r.data[rowOffset : rowOffset+rowLength], true
+}
+
+// Find returns the serialized message of the first row with the given key path, one key per (keys) field.
+// Keys are integers or strings, numeric strings match integer keys as in TableStore
+func (r *IndexedBinReader) Find(keys ...interface{}) ([]byte, bool) {
+	if len(keys) != len(r.keyFieldNames) {
+		return nil, false
+	}
+	path := make([]StoreKey, len(keys))
+	for i, key := range keys {
+		storeKey, err := (&TableStore{}).convertToStoreKey(key)
+		if err != nil {
+			return nil, false
+		}
+		path[i] = normalizeStoreKey(storeKey)
+	}
+
+	i := sort.Search(r.rowCount, func(i int) bool {
+		return compareKeyPaths(r.entryKeys(i), path) >= 0
+	})
+	if i == r.rowCount || compareKeyPaths(r.entryKeys(i), path) != 0 {
+		return nil, false
+	}
+	return r.Row(i)
+}
+
+// entry returns the bytes of the i-th index entry
+func (r *IndexedBinReader) entry(i int) []byte {
+	offset := r.indexOffset + i*r.entrySize
+	return r.data[offset : offset+r.entrySize]
+}
+
+// entryKeys decodes the key path of the i-th index entry
+func (r *IndexedBinReader) entryKeys(i int) []StoreKey {
+	entry := r.entry(i)
+	keys := make([]StoreKey, len(r.keyFieldNames))
+	for level := range keys {
+		keyBytes := entry[level*binIndexedKeySize : (level+1)*binIndexedKeySize]
+		if StoreKeyType(keyBytes[0]) == KeyTypeInteger {
+			keys[level] = StoreKey{KeyType: KeyTypeInteger, IntegerValue: int64(binary.BigEndian.Uint64(keyBytes[1:]))}
+			continue
+		}
+
+		start := r.poolOffset + int(binary.BigEndian.Uint32(keyBytes[1:]))
+		end := start + int(binary.BigEndian.Uint32(keyBytes[5:]))
+		if start > r.dataOffset || end > r.dataOffset {
+			// Corrupt strings read as empty rather than outside the string pool
+			start, end = r.poolOffset, r.poolOffset
+		}
+		keys[level] = StoreKey{KeyType: KeyTypeString, StringValue: string(r.data[start:end])}
+	}
+	return keys
+}
+
+// normalizeStoreKey turns numeric string keys into integer keys, as extractKeyFromMessage does
+func normalizeStoreKey(key StoreKey) StoreKey {
+	if key.KeyType == KeyTypeString {
+		if num, err := strconv.ParseInt(key.StringValue, 10, 64); err == nil {
+			return StoreKey{KeyType: KeyTypeInteger, IntegerValue: num}
+		}
+	}
+	return key
+}
@@ -0,0 +1,269 @@
+package protoxls
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/dynamic"
+)
+
+// indexedTestProto is the schema of the indexed binary tests
+const indexedTestProto = `syntax = "proto3";
+package indexed;
+
+message Item {
+    int32 id = 1;
+    string name = 2;
+    string kind = 3;
+    int32 level = 4;
+}
+`
+
+// indexedItem is a row of the indexed binary tests
+type indexedItem struct {
+	id    int32
+	name  string
+	kind  string
+	level int32
+}
+
+// newIndexedStore returns a store holding the items, split by the key fields
+func newIndexedStore(t *testing.T, items []indexedItem, keyFieldNames ...string) *TableStore {
+	parser := protoparse.Parser{Accessor: protoparse.FileContentsFromMap(map[string]string{"indexed.proto": indexedTestProto})}
+	files, err := parser.ParseFiles("indexed.proto")
+	if err != nil {
+		t.Fatalf("failed to parse test schema: %v", err)
+	}
+
+	msgDesc := files[0].FindMessage("indexed.Item")
+	store := NewTableStore(msgDesc)
+	for _, item := range items {
+		row := dynamic.NewMessage(msgDesc)
+		row.SetFieldByName("id", item.id)
+		row.SetFieldByName("name", item.name)
+		row.SetFieldByName("kind", item.kind)
+		row.SetFieldByName("level", item.level)
+		store.AddMessage(row)
+	}
+	if err := store.BuildHierarchicalStore(keyFieldNames); err != nil {
+		t.Fatalf("failed to build store: %v", err)
+	}
+	return store
+}
+
+// exportIndexed writes the store with the indexed binary format and opens the file written
+func exportIndexed(t *testing.T, store *TableStore) ([]byte, *IndexedBinReader) {
+	data := exportEscapeStore(t, store, func(outputDir string) Exporter {
+		return &BinExporter{OutputDir: outputDir, Format: BinFormatIndexed}
+	}, ".bin")
+	reader, err := NewIndexedBinReader(data)
+	if err != nil {
+		t.Fatalf("failed to read indexed binary: %v", err)
+	}
+	return data, reader
+}
+
+// decodeIndexedRow decodes a row found in an indexed binary file
+func decodeIndexedRow(t *testing.T, msgDesc *desc.MessageDescriptor, row []byte) *dynamic.Message {
+	message := dynamic.NewMessage(msgDesc)
+	if err := message.Unmarshal(row); err != nil {
+		t.Fatalf("failed to decode row: %v", err)
+	}
+	return message
+}
+
+// checkFind looks up a key path and checks that it returns the message of the want-th row of the store
+func checkFind(t *testing.T, store *TableStore, reader *IndexedBinReader, messages []*dynamic.Message, want int, keys ...interface{}) {
+	row, ok := reader.Find(keys...)
+	if !ok {
+		t.Errorf("Find(%v) found no row", keys)
+		return
+	}
+	if got := decodeIndexedRow(t, store.GetMessageDescriptor(), row); !dynamic.Equal(got, messages[want]) {
+		t.Errorf("Find(%v) = %v, want %v", keys, got, messages[want])
+	}
+}
+
+// checkMissing checks that a key path finds no row
+func checkMissing(t *testing.T, reader *IndexedBinReader, keys ...interface{}) {
+	if row, ok := reader.Find(keys...); ok {
+		t.Errorf("Find(%v) = %x, want no row", keys, row)
+	}
+}
+
+func TestIndexedBinIntegerKeys(t *testing.T) {
+	store := newIndexedStore(t, []indexedItem{
+		{id: 30, name: "c"},
+		{id: -1, name: "negative"},
+		{id: 10, name: "a"},
+		{id: 20, name: "b"},
+	}, "id")
+	messages := store.GetAllMessages()
+	_, reader := exportIndexed(t, store)
+
+	if reader.TableName() != GetTableName(store) || reader.MessageType() != "indexed.Item" {
+		t.Errorf("header names = %q, %q", reader.TableName(), reader.MessageType())
+	}
+	if !reflect.DeepEqual(reader.KeyFieldNames(), []string{"id"}) {
+		t.Errorf("KeyFieldNames() = %v", reader.KeyFieldNames())
+	}
+	if reader.Len() != len(messages) {
+		t.Fatalf("Len() = %d, want %d", reader.Len(), len(messages))
+	}
+
+	checkFind(t, store, reader, messages, 0, 30)
+	checkFind(t, store, reader, messages, 1, -1)
+	checkFind(t, store, reader, messages, 2, int64(10))
+	checkFind(t, store, reader, messages, 3, "20")
+	checkMissing(t, reader, 0)
+	checkMissing(t, reader, 15)
+	checkMissing(t, reader, 31)
+	checkMissing(t, reader, "b")
+	checkMissing(t, reader)
+	checkMissing(t, reader, 10, 10)
+
+	// Rows are in key order
+	for i, want := range []int{1, 2, 3, 0} {
+		row, ok := reader.Row(i)
+		if !ok {
+			t.Fatalf("Row(%d) found no row", i)
+		}
+		if got := decodeIndexedRow(t, store.GetMessageDescriptor(), row); !dynamic.Equal(got, messages[want]) {
+			t.Errorf("Row(%d) = %v, want %v", i, got, messages[want])
+		}
+	}
+	for _, i := range []int{-1, len(messages)} {
+		if _, ok := reader.Row(i); ok {
+			t.Errorf("Row(%d) found a row", i)
+		}
+	}
+}
+
+func TestIndexedBinStringKeys(t *testing.T) {
+	store := newIndexedStore(t, []indexedItem{
+		{id: 1, name: "sword"},
+		{id: 2, name: "axe"},
+		{id: 3, name: "007"},
+		{id: 4, name: ""},
+		{id: 5, name: "盾"},
+		{id: 6, name: "axe"},
+	}, "name")
+	messages := store.GetAllMessages()
+	_, reader := exportIndexed(t, store)
+
+	checkFind(t, store, reader, messages, 0, "sword")
+	checkFind(t, store, reader, messages, 1, "axe")
+	checkFind(t, store, reader, messages, 2, 7)
+	checkFind(t, store, reader, messages, 2, "007")
+	checkFind(t, store, reader, messages, 3, "")
+	checkFind(t, store, reader, messages, 4, "盾")
+	checkMissing(t, reader, "ax")
+	checkMissing(t, reader, "axes")
+	checkMissing(t, reader, "swords")
+	checkMissing(t, reader, 1)
+}
+
+func TestIndexedBinMultiLevelKeys(t *testing.T) {
+	store := newIndexedStore(t, []indexedItem{
+		{id: 1, kind: "sword", level: 2},
+		{id: 2, kind: "sword", level: 1},
+		{id: 3, kind: "shield", level: 1},
+		{id: 4, kind: "sword", level: 1},
+		{id: 5, kind: "bow", level: 3},
+	}, "kind", "level")
+	messages := store.GetAllMessages()
+	_, reader := exportIndexed(t, store)
+
+	if !reflect.DeepEqual(reader.KeyFieldNames(), []string{"kind", "level"}) {
+		t.Errorf("KeyFieldNames() = %v", reader.KeyFieldNames())
+	}
+	checkFind(t, store, reader, messages, 0, "sword", 2)
+	// Rows with equal keys keep their sheet order, so the first one is found
+	checkFind(t, store, reader, messages, 1, "sword", 1)
+	checkFind(t, store, reader, messages, 2, "shield", 1)
+	checkFind(t, store, reader, messages, 4, "bow", 3)
+	checkMissing(t, reader, "sword", 3)
+	checkMissing(t, reader, "bow", 1)
+	checkMissing(t, reader, "axe", 1)
+	checkMissing(t, reader, "sword")
+	checkMissing(t, reader, 1, "sword")
+}
+
+func TestIndexedBinCorruptHeader(t *testing.T) {
+	store := newIndexedStore(t, []indexedItem{
+		{id: 1, name: "a"},
+		{id: 2, name: "b"},
+	}, "name")
+	data, _ := exportIndexed(t, store)
+	dataOffset := binary.BigEndian.Uint64(data[24:])
+
+	// Every cut before the rows leaves the header, the index or the string pool incomplete
+	for size := 0; size < int(dataOffset); size++ {
+		if _, err := NewIndexedBinReader(data[:size]); err == nil {
+			t.Errorf("reading the first %d of %d bytes succeeded", size, len(data))
+		}
+	}
+
+	corruptions := []struct {
+		name   string
+		offset int
+		value  []byte
+	}{
+		{"magic", 0, []byte("PXLX")},
+		{"version", 4, []byte{0, 2}},
+		{"key level count", 6, []byte{0, 3}},
+		{"row count", 8, []byte{0, 0, 1, 0}},
+		{"index offset", 16, []byte{0, 0, 0, 0, 0, 0, 0, 0}},
+		{"data offset", 24, []byte{0, 0, 0, 0, 0, 1, 0, 0}},
+		{"data offset before the string pool", 24, []byte{0, 0, 0, 0, 0, 0, 0, 0}},
+		{"name length", 32, []byte{0xff, 0xff}},
+	}
+	for _, corruption := range corruptions {
+		corrupt := append([]byte(nil), data...)
+		copy(corrupt[corruption.offset:], corruption.value)
+		if _, err := NewIndexedBinReader(corrupt); err == nil {
+			t.Errorf("reading a file with a corrupt %s succeeded", corruption.name)
+		}
+	}
+}
+
+func TestIndexedBinCorruptIndex(t *testing.T) {
+	store := newIndexedStore(t, []indexedItem{
+		{id: 1, name: "a"},
+		{id: 2, name: "b"},
+	}, "name")
+	data, reader := exportIndexed(t, store)
+	entryOffset := int(binary.BigEndian.Uint64(data[16:]))
+
+	// Rows pointing outside the file are not found
+	for _, rowOffset := range []uint64{0, uint64(len(data)), 1<<64 - 1} {
+		corrupt := append([]byte(nil), data...)
+		binary.BigEndian.PutUint64(corrupt[entryOffset+binIndexedKeySize:], rowOffset)
+		if reader, err := NewIndexedBinReader(corrupt); err != nil {
+			t.Errorf("reading a file with row offset %d failed: %v", rowOffset, err)
+		} else if _, ok := reader.Row(0); ok {
+			t.Errorf("Row(0) with row offset %d found a row", rowOffset)
+		}
+	}
+
+	// Truncated rows are not found
+	if reader, err := NewIndexedBinReader(data[:len(data)-1]); err != nil {
+		t.Errorf("reading a file with a truncated row failed: %v", err)
+	} else if _, ok := reader.Find("b"); ok {
+		t.Errorf("Find(b) found a truncated row")
+	}
+
+	// String keys pointing outside the string pool read as empty
+	corrupt := append([]byte(nil), data...)
+	binary.BigEndian.PutUint32(corrupt[entryOffset+1:], 1<<32-1)
+	if reader, err := NewIndexedBinReader(corrupt); err != nil {
+		t.Errorf("reading a file with a corrupt string key failed: %v", err)
+	} else {
+		checkMissing(t, reader, "a")
+	}
+
+	checkFind(t, store, reader, store.GetAllMessages(), 1, "b")
+}
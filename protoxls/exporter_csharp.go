@@ -0,0 +1,859 @@
+package protoxls
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/jhump/protoreflect/desc"
+)
+
+const (
+	// CSharpNamingPascal follows C# conventions: PascalCase properties, and enum values in PascalCase
+	// without the prefix repeating the enum name, as protoc does
+	CSharpNamingPascal = "pascal"
+	// CSharpNamingProto keeps the proto names of fields and enum values
+	CSharpNamingProto = "proto"
+
+	// CSharpLoaderJSON generates loaders reading the JSON output with Newtonsoft.Json
+	CSharpLoaderJSON = "json"
+	// CSharpLoaderBinary generates loaders reading the binary output without dependencies
+	CSharpLoaderBinary = "bin"
+
+	// CSharpRuntimeFileName is the base name of the file holding the helpers shared by all loaders
+	CSharpRuntimeFileName = "Protoxls"
+	// csharpRuntimeNamespace is the namespace of the helpers if no namespace is configured
+	csharpRuntimeNamespace = "Protoxls"
+	// csharpIndent is the indentation of one block level
+	csharpIndent = "    "
+)
+
+// csharpKeywords are the reserved words of C#, which need an @ prefix to be used as identifiers
+var csharpKeywords = map[string]bool{
+	"abstract": true, "as": true, "base": true, "bool": true, "break": true, "byte": true, "case": true,
+	"catch": true, "char": true, "checked": true, "class": true, "const": true, "continue": true,
+	"decimal": true, "default": true, "delegate": true, "do": true, "double": true, "else": true,
+	"enum": true, "event": true, "explicit": true, "extern": true, "false": true, "finally": true,
+	"fixed": true, "float": true, "for": true, "foreach": true, "goto": true, "if": true,
+	"implicit": true, "in": true, "int": true, "interface": true, "internal": true, "is": true,
+	"lock": true, "long": true, "namespace": true, "new": true, "null": true, "object": true,
+	"operator": true, "out": true, "override": true, "params": true, "private": true,
+	"protected": true, "public": true, "readonly": true, "ref": true, "return": true, "sbyte": true,
+	"sealed": true, "short": true, "sizeof": true, "stackalloc": true, "static": true, "string": true,
+	"struct": true, "switch": true, "this": true, "throw": true, "true": true, "try": true,
+	"typeof": true, "uint": true, "ulong": true, "unchecked": true, "unsafe": true, "ushort": true,
+	"using": true, "virtual": true, "void": true, "volatile": true, "while": true,
+}
+
+// IsValidCSharpNaming checks if a string is one of the C# naming conventions
+func IsValidCSharpNaming(naming string) bool {
+	return naming == CSharpNamingPascal || naming == CSharpNamingProto
+}
+
+// IsValidCSharpLoader checks if a string is one of the outputs C# loaders can read
+func IsValidCSharpLoader(loader string) bool {
+	return loader == CSharpLoaderJSON || loader == CSharpLoaderBinary
+}
+
+// CSharpExporter generates C# classes and enums for the tables, and a loader per table building
+// Dictionary lookups along the (keys) hierarchy from the JSON or binary output
+type CSharpExporter struct {
+	OutputDir  string // Custom output directory, defaults to DefaultOutputDir if empty
+	Namespace  string // Namespace of all generated code, the csharp_namespace or package of each proto file if empty
+	Naming     string // Naming convention of properties and enum values, CSharpNamingPascal if empty
+	Loader     string // Output read by the loaders, CSharpLoaderJSON if empty
+	JsonStyle  string // JSON mapping style of the JSON output, JsonStyleDefault if empty
+	EnumFormat string // How the JSON output exports enum fields, EnumFormatNumber if empty
+
+	// Top-level types already written for a previous table, each type is defined once per run
+	writtenTypes   map[string]bool
+	runtimeWritten bool
+}
+
+// ExportResult generates the loader of the table and the types it uses that no previous table used
+func (ce *CSharpExporter) ExportResult(store *TableStore) error {
+	if ce.writtenTypes == nil {
+		ce.writtenTypes = make(map[string]bool)
+	}
+	if !ce.runtimeWritten {
+		if err := ce.writeRuntime(); err != nil {
+			return err
+		}
+		ce.runtimeWritten = true
+	}
+
+	keyFields, err := getTableKeyFields(store)
+	if err != nil {
+		return err
+	}
+
+	file, err := CreateOutputFile(store, ce.OutputDir, "cs")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	msgDesc := store.GetMessageDescriptor()
+	cw := &csharpWriter{}
+	cw.line("// <auto-generated>")
+	cw.line("// Generated by protoxls from %s, do not edit.", msgDesc.GetFile().GetName())
+	cw.line("// </auto-generated>")
+	cw.line("using System.Collections.Generic;")
+	if ce.Loader != CSharpLoaderBinary {
+		cw.line("using System.Runtime.Serialization;")
+		cw.line("using Newtonsoft.Json;")
+	}
+
+	// Types go first, each in the namespace of its proto file, then the loader next to the row type
+	for _, typeDesc := range ce.collectNewTypes(msgDesc) {
+		cw.openNamespace(ce.namespaceOf(typeDesc.GetFile()))
+		if enumDesc, ok := typeDesc.(*desc.EnumDescriptor); ok {
+			ce.writeEnum(cw, enumDesc)
+		} else {
+			ce.writeMessage(cw, typeDesc.(*desc.MessageDescriptor))
+		}
+	}
+	cw.openNamespace(ce.namespaceOf(msgDesc.GetFile()))
+	ce.writeTable(cw, store, keyFields)
+	cw.closeNamespace()
+
+	if _, err := file.WriteString(cw.String()); err != nil {
+		return fmt.Errorf("failed to write C# code: %v", err)
+	}
+	return nil
+}
+
+// collectNewTypes returns the top-level messages and enums used by a row message that are not written yet,
+// the row message first. Nested types are written with their top-level message
+func (ce *CSharpExporter) collectNewTypes(msgDesc *desc.MessageDescriptor) []desc.Descriptor {
+	var types []desc.Descriptor
+
+	var visitMessage func(msgDesc *desc.MessageDescriptor)
+	var visitType func(typeDesc desc.Descriptor)
+	visitType = func(typeDesc desc.Descriptor) {
+		for {
+			parent, ok := typeDesc.GetParent().(*desc.MessageDescriptor)
+			if !ok {
+				break
+			}
+			typeDesc = parent
+		}
+		if ce.writtenTypes[typeDesc.GetFullyQualifiedName()] {
+			return
+		}
+		ce.writtenTypes[typeDesc.GetFullyQualifiedName()] = true
+		types = append(types, typeDesc)
+		if topMessage, ok := typeDesc.(*desc.MessageDescriptor); ok {
+			visitMessage(topMessage)
+		}
+	}
+	visitMessage = func(msgDesc *desc.MessageDescriptor) {
+		for _, field := range msgDesc.GetFields() {
+			valueField := field
+			if field.IsMap() {
+				valueField = field.GetMapValueType()
+			}
+			if valueField.GetMessageType() != nil {
+				visitType(valueField.GetMessageType())
+			}
+			if valueField.GetEnumType() != nil {
+				visitType(valueField.GetEnumType())
+			}
+		}
+		for _, nested := range msgDesc.GetNestedMessageTypes() {
+			if !nested.IsMapEntry() {
+				visitMessage(nested)
+			}
+		}
+	}
+
+	visitType(msgDesc)
+	return types
+}
+
+// writeMessage writes the class of a message with its nested types
+func (ce *CSharpExporter) writeMessage(cw *csharpWriter, msgDesc *desc.MessageDescriptor) {
+	namespace := ce.namespaceOf(msgDesc.GetFile())
+	className := csharpIdentifier(msgDesc.GetName())
+
+	cw.docComment(getDescriptorComment(msgDesc))
+	cw.line("public partial class %s", className)
+	cw.open()
+	for i, field := range getSortedFields(msgDesc) {
+		if i > 0 {
+			cw.line("")
+		}
+		cw.docComment(strings.Join(getFieldDescription(field), "\n"))
+		if ce.Loader != CSharpLoaderBinary {
+			cw.line("[JsonProperty(%s)]", quoteCSharpString(ce.jsonFieldName(field)))
+		}
+		declaration := fmt.Sprintf("public %s %s { get; set; }", ce.fieldType(field, namespace), ce.propertyName(field))
+		if initializer := ce.fieldInitializer(field, namespace); initializer != "" {
+			declaration += " = " + initializer + ";"
+		}
+		cw.line("%s", declaration)
+	}
+
+	if ce.Loader == CSharpLoaderBinary {
+		if len(msgDesc.GetFields()) > 0 {
+			cw.line("")
+		}
+		ce.writeMergeFrom(cw, msgDesc)
+	}
+
+	var nestedTypes []desc.Descriptor
+	for _, nested := range msgDesc.GetNestedMessageTypes() {
+		if !nested.IsMapEntry() {
+			nestedTypes = append(nestedTypes, nested)
+		}
+	}
+	for _, nested := range msgDesc.GetNestedEnumTypes() {
+		nestedTypes = append(nestedTypes, nested)
+	}
+	if len(nestedTypes) > 0 {
+		// Nested types live in a Types class as protoc generates them, so they cannot clash with properties
+		cw.line("")
+		cw.docComment("Types nested in " + msgDesc.GetName())
+		cw.line("public static partial class Types")
+		cw.open()
+		for i, nested := range nestedTypes {
+			if i > 0 {
+				cw.line("")
+			}
+			if enumDesc, ok := nested.(*desc.EnumDescriptor); ok {
+				ce.writeEnum(cw, enumDesc)
+			} else {
+				ce.writeMessage(cw, nested.(*desc.MessageDescriptor))
+			}
+		}
+		cw.close()
+	}
+	cw.close()
+}
+
+// writeEnum writes an enum with one member per value, commented with its (alias)
+func (ce *CSharpExporter) writeEnum(cw *csharpWriter, enumDesc *desc.EnumDescriptor) {
+	enumName := csharpIdentifier(enumDesc.GetName())
+	cw.docComment(getDescriptorComment(enumDesc))
+	cw.line("public enum %s", enumName)
+	cw.open()
+	for _, enumVal := range enumDesc.GetValues() {
+		var description []string
+		if displayName := getEnumDisplayName(enumVal); displayName != enumVal.GetName() {
+			description = append(description, displayName)
+		}
+		if comment := getDescriptorComment(enumVal); comment != "" {
+			description = append(description, comment)
+		}
+		cw.docComment(strings.Join(description, "\n"))
+
+		memberName := ce.enumValueName(enumVal)
+		if ce.Loader != CSharpLoaderBinary && memberName != enumVal.GetName() {
+			// Lets StringEnumConverter read the proto names written for name formatted enums
+			cw.line("[EnumMember(Value = %s)]", quoteCSharpString(enumVal.GetName()))
+		}
+		cw.line("%s = %d,", memberName, enumVal.GetNumber())
+	}
+	cw.close()
+}
+
+// writeMergeFrom writes the method decoding the fields of a message from the binary output
+func (ce *CSharpExporter) writeMergeFrom(cw *csharpWriter, msgDesc *desc.MessageDescriptor) {
+	namespace := ce.namespaceOf(msgDesc.GetFile())
+	cw.docComment("Merges the fields of a serialized " + msgDesc.GetName() + " into this one")
+	cw.line("public void MergeFrom(%s reader)", ce.runtimeType("ProtoxlsReader"))
+	cw.open()
+	cw.line("int number, wireType;")
+	cw.line("while (reader.ReadTag(out number, out wireType))")
+	cw.open()
+	cw.line("switch (number)")
+	cw.open()
+	for _, field := range getSortedFields(msgDesc) {
+		property := ce.propertyName(field)
+		cw.line("case %d:", field.GetNumber())
+		cw.open()
+		switch {
+		case field.IsMap():
+			keyField, valueField := field.GetMapKeyType(), field.GetMapValueType()
+			cw.line("var entry = reader.ReadMessage();")
+			cw.line("var key = %s;", ce.defaultValue(keyField, namespace))
+			cw.line("var value = %s;", ce.defaultValue(valueField, namespace))
+			cw.line("int entryNumber, entryWireType;")
+			cw.line("while (entry.ReadTag(out entryNumber, out entryWireType))")
+			cw.open()
+			cw.line("if (entryNumber == 1)")
+			cw.open()
+			cw.line("key = %s;", ce.readValue(keyField, "entry", namespace))
+			cw.close()
+			cw.line("else if (entryNumber == 2)")
+			cw.open()
+			if valueField.GetMessageType() != nil {
+				cw.line("value.MergeFrom(entry.ReadMessage());")
+			} else {
+				cw.line("value = %s;", ce.readValue(valueField, "entry", namespace))
+			}
+			cw.close()
+			cw.line("else")
+			cw.open()
+			cw.line("entry.SkipField(entryWireType);")
+			cw.close()
+			cw.close()
+			cw.line("%s[key] = value;", property)
+		case field.GetMessageType() != nil && field.IsRepeated():
+			cw.line("var item = new %s();", ce.typeRef(field.GetMessageType(), namespace))
+			cw.line("item.MergeFrom(reader.ReadMessage());")
+			cw.line("%s.Add(item);", property)
+		case field.GetMessageType() != nil:
+			cw.line("if (%s == null)", property)
+			cw.open()
+			cw.line("%s = new %s();", property, ce.typeRef(field.GetMessageType(), namespace))
+			cw.close()
+			cw.line("%s.MergeFrom(reader.ReadMessage());", property)
+		case field.IsRepeated() && isPackableField(field):
+			// Repeated numbers are packed by proto3 writers, but unpacked values must be accepted as well
+			cw.line("if (wireType == 2)")
+			cw.open()
+			cw.line("var packed = reader.ReadMessage();")
+			cw.line("while (!packed.IsAtEnd)")
+			cw.open()
+			cw.line("%s.Add(%s);", property, ce.readValue(field, "packed", namespace))
+			cw.close()
+			cw.close()
+			cw.line("else")
+			cw.open()
+			cw.line("%s.Add(%s);", property, ce.readValue(field, "reader", namespace))
+			cw.close()
+		case field.IsRepeated():
+			cw.line("%s.Add(%s);", property, ce.readValue(field, "reader", namespace))
+		default:
+			cw.line("%s = %s;", property, ce.readValue(field, "reader", namespace))
+		}
+		cw.line("break;")
+		cw.close()
+	}
+	cw.line("default:")
+	cw.open()
+	cw.line("reader.SkipField(wireType);")
+	cw.line("break;")
+	cw.close()
+	cw.close()
+	cw.close()
+	cw.close()
+}
+
+// writeTable writes the loader class of a table, holding its rows along the (keys) hierarchy
+func (ce *CSharpExporter) writeTable(cw *csharpWriter, store *TableStore, keyFields []*desc.FieldDescriptor) {
+	msgDesc := store.GetMessageDescriptor()
+	namespace := ce.namespaceOf(msgDesc.GetFile())
+	rowType := csharpIdentifier(msgDesc.GetName())
+	tableClass := csharpIdentifier(msgDesc.GetName() + "Table")
+
+	fileName := GetTableName(store) + ".json"
+	if ce.Loader == CSharpLoaderBinary {
+		fileName = GetTableName(store) + ".bin"
+	}
+
+	// Types of the nested dictionaries, levelTypes[i] holds the rows below the i-th key
+	levelTypes := make([]string, len(keyFields)+1)
+	levelTypes[len(keyFields)] = rowType
+	for i := len(keyFields) - 1; i >= 0; i-- {
+		levelTypes[i] = fmt.Sprintf("Dictionary<%s, %s>", ce.elementType(keyFields[i], namespace), levelTypes[i+1])
+	}
+	rowsType := levelTypes[0]
+	if len(keyFields) == 0 {
+		rowsType = fmt.Sprintf("List<%s>", rowType)
+	}
+
+	keyNames := make([]string, len(keyFields))
+	keyParams := make([]string, len(keyFields))
+	for i, keyField := range keyFields {
+		keyNames[i] = keyField.GetName()
+		keyParams[i] = ce.parameterName(keyField)
+	}
+
+	description := fmt.Sprintf("Rows of %s", GetTableName(store))
+	if len(keyFields) > 0 {
+		description += " by " + strings.Join(keyNames, ", ")
+	} else {
+		description += " in sheet order"
+	}
+	cw.docComment(fmt.Sprintf("%s, loaded from %s", description, fileName))
+	cw.line("public partial class %s", tableClass)
+	cw.open()
+	cw.docComment("Name of the exported file the table is loaded from")
+	cw.line("public const string FileName = %s;", quoteCSharpString(fileName))
+	cw.line("")
+	cw.docComment(description)
+	cw.line("public %s Rows { get; private set; } = new %s();", rowsType, rowsType)
+
+	cw.line("")
+	if ce.Loader == CSharpLoaderBinary {
+		cw.docComment("Loads the table from the contents of " + fileName)
+		cw.line("public static %s FromBytes(byte[] data)", tableClass)
+		cw.open()
+		cw.line("var table = new %s();", tableClass)
+		cw.line("foreach (var rowReader in %s.Read(data))", ce.runtimeType("ProtoxlsRows"))
+		cw.open()
+		cw.line("var row = new %s();", rowType)
+		cw.line("row.MergeFrom(rowReader);")
+		cw.line("table.AddRow(row);")
+		cw.close()
+		cw.line("return table;")
+		cw.close()
+		cw.line("")
+		ce.writeAddRow(cw, rowType, keyFields, levelTypes)
+	} else {
+		cw.docComment("Loads the table from the contents of " + fileName)
+		cw.line("public static %s FromJson(string json)", tableClass)
+		cw.open()
+		cw.line("var table = new %s();", tableClass)
+		cw.line("table.Rows = JsonConvert.DeserializeObject<%s>(json, %s.Settings) ?? new %s();", rowsType, ce.runtimeType("ProtoxlsJson"), rowsType)
+		cw.line("return table;")
+		cw.close()
+	}
+
+	if len(keyFields) > 0 {
+		var params, args []string
+		for i, keyField := range keyFields {
+			params = append(params, fmt.Sprintf("%s %s", ce.elementType(keyField, namespace), keyParams[i]))
+			args = append(args, keyParams[i])
+		}
+
+		cw.line("")
+		cw.docComment(fmt.Sprintf("Returns the row with the given %s, or null if there is none", strings.Join(keyNames, ", ")))
+		cw.line("public %s Get(%s)", rowType, strings.Join(params, ", "))
+		cw.open()
+		cw.line("%s row;", rowType)
+		cw.line("return TryGet(%s, out row) ? row : null;", strings.Join(args, ", "))
+		cw.close()
+
+		cw.line("")
+		cw.docComment(fmt.Sprintf("Looks up the row with the given %s", strings.Join(keyNames, ", ")))
+		cw.line("public bool TryGet(%s, out %s row)", strings.Join(params, ", "), rowType)
+		cw.open()
+		if len(keyFields) > 1 {
+			cw.line("row = null;")
+		}
+		level := "Rows"
+		for i := 0; i < len(keyFields)-1; i++ {
+			cw.line("%s level%d;", levelTypes[i+1], i+1)
+			cw.line("if (!%s.TryGetValue(%s, out level%d))", level, keyParams[i], i+1)
+			cw.open()
+			cw.line("return false;")
+			cw.close()
+			level = fmt.Sprintf("level%d", i+1)
+		}
+		cw.line("return %s.TryGetValue(%s, out row);", level, keyParams[len(keyFields)-1])
+		cw.close()
+	}
+	cw.close()
+}
+
+// writeAddRow writes the method adding a decoded row to the (keys) hierarchy. Like the JSON output,
+// the first row of each key path is kept
+func (ce *CSharpExporter) writeAddRow(cw *csharpWriter, rowType string, keyFields []*desc.FieldDescriptor, levelTypes []string) {
+	cw.line("private void AddRow(%s row)", rowType)
+	cw.open()
+	if len(keyFields) == 0 {
+		cw.line("Rows.Add(row);")
+		cw.close()
+		return
+	}
+
+	level := "Rows"
+	for i := 0; i < len(keyFields)-1; i++ {
+		key := "row." + ce.propertyName(keyFields[i])
+		next := fmt.Sprintf("level%d", i+1)
+		cw.line("%s %s;", levelTypes[i+1], next)
+		cw.line("if (!%s.TryGetValue(%s, out %s))", level, key, next)
+		cw.open()
+		cw.line("%s = new %s();", next, levelTypes[i+1])
+		cw.line("%s[%s] = %s;", level, key, next)
+		cw.close()
+		level = next
+	}
+	key := "row." + ce.propertyName(keyFields[len(keyFields)-1])
+	cw.line("if (!%s.ContainsKey(%s))", level, key)
+	cw.open()
+	cw.line("%s[%s] = row;", level, key)
+	cw.close()
+	cw.close()
+}
+
+// writeRuntime writes the helpers shared by the loaders of all tables
+func (ce *CSharpExporter) writeRuntime() error {
+	file, err := CreateNamedOutputFile(CSharpRuntimeFileName, ce.OutputDir, "cs")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	source := csharpJSONRuntime
+	if ce.Loader == CSharpLoaderBinary {
+		source = csharpBinaryRuntime
+	}
+	source = strings.Replace(source, "namespace "+csharpRuntimeNamespace+"\n", "namespace "+ce.runtimeNamespace()+"\n", 1)
+	if _, err := file.WriteString(source); err != nil {
+		return fmt.Errorf("failed to write C# runtime: %v", err)
+	}
+	return nil
+}
+
+// runtimeNamespace returns the namespace of the shared helpers
+func (ce *CSharpExporter) runtimeNamespace() string {
+	if ce.Namespace != "" {
+		return ce.Namespace
+	}
+	return csharpRuntimeNamespace
+}
+
+// runtimeType returns the qualified name of a shared helper
+func (ce *CSharpExporter) runtimeType(name string) string {
+	return "global::" + ce.runtimeNamespace() + "." + name
+}
+
+// namespaceOf returns the namespace of the types of a proto file: the configured namespace, the
+// csharp_namespace option or the package in PascalCase, empty for the global namespace
+func (ce *CSharpExporter) namespaceOf(fd *desc.FileDescriptor) string {
+	if ce.Namespace != "" {
+		return ce.Namespace
+	}
+	if namespace := fd.GetFileOptions().GetCsharpNamespace(); namespace != "" {
+		return namespace
+	}
+	var parts []string
+	for _, part := range strings.Split(fd.GetPackage(), ".") {
+		if part != "" {
+			parts = append(parts, csharpIdentifier(pascalCase(part)))
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// typeRef returns the name of a message or enum as written in the given namespace
+func (ce *CSharpExporter) typeRef(typeDesc desc.Descriptor, namespace string) string {
+	path := csharpIdentifier(typeDesc.GetName())
+	for parent, ok := typeDesc.GetParent().(*desc.MessageDescriptor); ok; parent, ok = parent.GetParent().(*desc.MessageDescriptor) {
+		path = csharpIdentifier(parent.GetName()) + ".Types." + path
+	}
+
+	typeNamespace := ce.namespaceOf(typeDesc.GetFile())
+	switch {
+	case typeNamespace == namespace:
+		return path
+	case typeNamespace == "":
+		return "global::" + path
+	default:
+		return "global::" + typeNamespace + "." + path
+	}
+}
+
+// fieldType returns the property type of a field, including repeated and map fields
+func (ce *CSharpExporter) fieldType(field *desc.FieldDescriptor, namespace string) string {
+	switch {
+	case field.IsMap():
+		return fmt.Sprintf("Dictionary<%s, %s>", ce.elementType(field.GetMapKeyType(), namespace), ce.elementType(field.GetMapValueType(), namespace))
+	case field.IsRepeated():
+		return fmt.Sprintf("List<%s>", ce.elementType(field, namespace))
+	default:
+		return ce.elementType(field, namespace)
+	}
+}
+
+// elementType maps the type of a single field value to its C# type
+func (ce *CSharpExporter) elementType(field *desc.FieldDescriptor, namespace string) string {
+	switch field.GetType().String() {
+	case "TYPE_INT32", "TYPE_SINT32", "TYPE_SFIXED32":
+		return "int"
+	case "TYPE_INT64", "TYPE_SINT64", "TYPE_SFIXED64":
+		return "long"
+	case "TYPE_UINT32", "TYPE_FIXED32":
+		return "uint"
+	case "TYPE_UINT64", "TYPE_FIXED64":
+		return "ulong"
+	case "TYPE_FLOAT":
+		return "float"
+	case "TYPE_DOUBLE":
+		return "double"
+	case "TYPE_BOOL":
+		return "bool"
+	case "TYPE_STRING":
+		return "string"
+	case "TYPE_BYTES":
+		return "byte[]"
+	case "TYPE_ENUM":
+		if ce.isEnumString(field) {
+			return "string"
+		}
+		return ce.typeRef(field.GetEnumType(), namespace)
+	}
+	return ce.typeRef(field.GetMessageType(), namespace)
+}
+
+// isEnumString reports whether the JSON output writes an enum field as its (alias), which has no enum member
+func (ce *CSharpExporter) isEnumString(field *desc.FieldDescriptor) bool {
+	return ce.Loader != CSharpLoaderBinary && ce.JsonStyle != JsonStyleProtoJSON &&
+		getEnumFormat(field, ce.EnumFormat) == EnumFormatAlias
+}
+
+// fieldInitializer returns the initial value of a property, empty for null
+func (ce *CSharpExporter) fieldInitializer(field *desc.FieldDescriptor, namespace string) string {
+	switch {
+	case field.IsMap() || field.IsRepeated():
+		return "new " + ce.fieldType(field, namespace) + "()"
+	case field.GetMessageType() != nil:
+		return ""
+	}
+	switch field.GetType().String() {
+	case "TYPE_STRING":
+		return `""`
+	case "TYPE_BYTES":
+		return "new byte[0]"
+	case "TYPE_ENUM":
+		if ce.isEnumString(field) {
+			return `""`
+		}
+	}
+	return ""
+}
+
+// defaultValue returns the value of an absent map key or value, which is never null
+func (ce *CSharpExporter) defaultValue(field *desc.FieldDescriptor, namespace string) string {
+	switch field.GetType().String() {
+	case "TYPE_STRING":
+		return `""`
+	case "TYPE_BYTES":
+		return "new byte[0]"
+	case "TYPE_MESSAGE":
+		return "new " + ce.typeRef(field.GetMessageType(), namespace) + "()"
+	}
+	return fmt.Sprintf("default(%s)", ce.elementType(field, namespace))
+}
+
+// readValue returns the expression decoding a scalar or enum value of a field with the named reader
+func (ce *CSharpExporter) readValue(field *desc.FieldDescriptor, reader, namespace string) string {
+	switch field.GetType().String() {
+	case "TYPE_INT32":
+		return fmt.Sprintf("(int)%s.ReadVarint()", reader)
+	case "TYPE_INT64":
+		return fmt.Sprintf("(long)%s.ReadVarint()", reader)
+	case "TYPE_UINT32":
+		return fmt.Sprintf("(uint)%s.ReadVarint()", reader)
+	case "TYPE_UINT64":
+		return fmt.Sprintf("%s.ReadVarint()", reader)
+	case "TYPE_SINT32":
+		return fmt.Sprintf("%s.ReadSInt32()", reader)
+	case "TYPE_SINT64":
+		return fmt.Sprintf("%s.ReadSInt64()", reader)
+	case "TYPE_FIXED32":
+		return fmt.Sprintf("%s.ReadFixed32()", reader)
+	case "TYPE_SFIXED32":
+		return fmt.Sprintf("(int)%s.ReadFixed32()", reader)
+	case "TYPE_FIXED64":
+		return fmt.Sprintf("%s.ReadFixed64()", reader)
+	case "TYPE_SFIXED64":
+		return fmt.Sprintf("(long)%s.ReadFixed64()", reader)
+	case "TYPE_FLOAT":
+		return fmt.Sprintf("%s.ReadFloat()", reader)
+	case "TYPE_DOUBLE":
+		return fmt.Sprintf("%s.ReadDouble()", reader)
+	case "TYPE_BOOL":
+		return fmt.Sprintf("%s.ReadVarint() != 0", reader)
+	case "TYPE_STRING":
+		return fmt.Sprintf("%s.ReadString()", reader)
+	case "TYPE_BYTES":
+		return fmt.Sprintf("%s.ReadBytes()", reader)
+	case "TYPE_ENUM":
+		return fmt.Sprintf("(%s)(int)%s.ReadVarint()", ce.typeRef(field.GetEnumType(), namespace), reader)
+	}
+	// Messages are merged by the caller
+	return ""
+}
+
+// isPackableField reports whether repeated values of a field may be written packed
+func isPackableField(field *desc.FieldDescriptor) bool {
+	switch field.GetType().String() {
+	case "TYPE_STRING", "TYPE_BYTES", "TYPE_MESSAGE", "TYPE_GROUP":
+		return false
+	}
+	return true
+}
+
+// jsonFieldName returns the key of a field in the JSON output
+func (ce *CSharpExporter) jsonFieldName(field *desc.FieldDescriptor) string {
+	if ce.JsonStyle == JsonStyleProtoJSON {
+		return field.GetJSONName()
+	}
+	return field.GetName()
+}
+
+// propertyName returns the property of a field, which must differ from the members of its class
+func (ce *CSharpExporter) propertyName(field *desc.FieldDescriptor) string {
+	name := field.GetName()
+	if ce.Naming != CSharpNamingProto {
+		name = pascalCase(name)
+	}
+
+	msgDesc := field.GetOwner()
+	reserved := name == msgDesc.GetName() ||
+		name == "Types" && (len(msgDesc.GetNestedMessageTypes()) > 0 || len(msgDesc.GetNestedEnumTypes()) > 0) ||
+		name == "MergeFrom" && ce.Loader == CSharpLoaderBinary
+	if reserved {
+		name += "_"
+	}
+	return csharpIdentifier(name)
+}
+
+// parameterName returns the lookup parameter of a (keys) field
+func (ce *CSharpExporter) parameterName(field *desc.FieldDescriptor) string {
+	name := field.GetName()
+	if ce.Naming != CSharpNamingProto {
+		name = pascalCase(name)
+		name = strings.ToLower(name[:1]) + name[1:]
+	}
+	if name == "row" {
+		name += "_"
+	}
+	return csharpIdentifier(name)
+}
+
+// enumValueName returns the member of an enum value. In PascalCase the prefix repeating the enum name
+// is dropped, so HERO_TYPE_WARRIOR of HeroType becomes Warrior
+func (ce *CSharpExporter) enumValueName(enumVal *desc.EnumValueDescriptor) string {
+	name := enumVal.GetName()
+	enumName := enumVal.GetEnum().GetName()
+	if ce.Naming != CSharpNamingProto {
+		prefix := strings.ToUpper(strings.ReplaceAll(snakeCase(enumName), "_", ""))
+		normalized := strings.ToUpper(strings.ReplaceAll(name, "_", ""))
+		if strings.HasPrefix(normalized, prefix) && len(normalized) > len(prefix) {
+			// Drop the prefix with the underscores it contains, then the separating underscores
+			matched := 0
+			for i := 0; i < len(name); i++ {
+				if name[i] != '_' {
+					matched++
+				}
+				if matched == len(prefix) {
+					name = strings.TrimLeft(name[i+1:], "_")
+					break
+				}
+			}
+		}
+		name = pascalCase(strings.ToLower(name))
+	}
+	if name != "" && unicode.IsDigit(rune(name[0])) {
+		name = "_" + name
+	}
+	if name == enumName {
+		name += "_"
+	}
+	return csharpIdentifier(name)
+}
+
+// csharpIdentifier escapes reserved words
+func csharpIdentifier(name string) string {
+	if csharpKeywords[name] {
+		return "@" + name
+	}
+	return name
+}
+
+// pascalCase converts a snake_case name to PascalCase like protoc: underscores are removed, and the first
+// letter and letters following an underscore or digit are capitalized
+func pascalCase(name string) string {
+	var result strings.Builder
+	capitalizeNext := true
+	for _, r := range name {
+		switch {
+		case r == '_':
+			capitalizeNext = true
+		case unicode.IsDigit(r):
+			result.WriteRune(r)
+			capitalizeNext = true
+		case capitalizeNext:
+			result.WriteRune(unicode.ToUpper(r))
+			capitalizeNext = false
+		default:
+			result.WriteRune(r)
+		}
+	}
+	return result.String()
+}
+
+// snakeCase converts a PascalCase or camelCase name to snake_case
+func snakeCase(name string) string {
+	var result strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				result.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		result.WriteRune(r)
+	}
+	return result.String()
+}
+
+// csharpWriter builds C# source line by line with block indentation
+type csharpWriter struct {
+	strings.Builder
+	depth     int
+	namespace *string // Namespace block currently open, nil if none
+}
+
+// line writes an indented line
+func (cw *csharpWriter) line(format string, args ...interface{}) {
+	text := fmt.Sprintf(format, args...)
+	if text != "" {
+		cw.WriteString(strings.Repeat(csharpIndent, cw.depth))
+	}
+	cw.WriteString(text + "\n")
+}
+
+// open starts a block
+func (cw *csharpWriter) open() {
+	cw.line("{")
+	cw.depth++
+}
+
+// close ends a block
+func (cw *csharpWriter) close() {
+	cw.depth--
+	cw.line("}")
+}
+
+// openNamespace continues the open namespace block if it matches, or closes it and opens the namespace
+func (cw *csharpWriter) openNamespace(namespace string) {
+	if cw.namespace != nil && *cw.namespace == namespace {
+		cw.line("")
+		return
+	}
+	cw.closeNamespace()
+	cw.line("")
+	if namespace != "" {
+		cw.line("namespace %s", namespace)
+		cw.open()
+	}
+	cw.namespace = &namespace
+}
+
+// closeNamespace closes the open namespace block, if any
+func (cw *csharpWriter) closeNamespace() {
+	if cw.namespace != nil && *cw.namespace != "" {
+		cw.close()
+	}
+	cw.namespace = nil
+}
+
+// docComment writes an XML documentation summary, nothing for an empty text
+func (cw *csharpWriter) docComment(text string) {
+	if text == "" {
+		return
+	}
+	escaper := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	cw.line("/// <summary>")
+	for _, line := range strings.Split(text, "\n") {
+		cw.line("/// %s", strings.TrimSpace(escaper.Replace(line)))
+	}
+	cw.line("/// </summary>")
+}
@@ -0,0 +1,447 @@
+package protoxls
+
+// csharpJSONRuntime holds the helpers of loaders reading the JSON output with Newtonsoft.Json
+const csharpJSONRuntime = `// <auto-generated>
+// Generated by protoxls, do not edit.
+// </auto-generated>
+using Newtonsoft.Json;
+using Newtonsoft.Json.Converters;
+
+namespace Protoxls
+{
+    /// <summary>
+    /// Settings of Newtonsoft.Json for the JSON output of protoxls
+    /// </summary>
+    public static class ProtoxlsJson
+    {
+        /// <summary>
+        /// Reads enums from their numbers or proto names, and ignores fields the generated classes do not know
+        /// </summary>
+        public static readonly JsonSerializerSettings Settings = new JsonSerializerSettings
+        {
+            Converters = { new StringEnumConverter() },
+            MissingMemberHandling = MissingMemberHandling.Ignore,
+        };
+    }
+}
+`
+
+// csharpBinaryRuntime holds the protobuf wire format reader of loaders reading the binary output
+const csharpBinaryRuntime = `// <auto-generated>
+// Generated by protoxls, do not edit.
+// </auto-generated>
+using System;
+using System.Collections.Generic;
+using System.Text;
+
+namespace Protoxls
+{
+    /// <summary>
+    /// Reads fields of a message in the protobuf wire format
+    /// </summary>
+    public sealed class ProtoxlsReader
+    {
+        private readonly byte[] buffer;
+        private readonly int limit;
+        private int position;
+
+        public ProtoxlsReader(byte[] buffer) : this(buffer, 0, buffer.Length)
+        {
+        }
+
+        public ProtoxlsReader(byte[] buffer, int offset, int length)
+        {
+            if (offset < 0 || length < 0 || offset > buffer.Length - length)
+            {
+                throw new FormatException("Message out of bounds");
+            }
+            this.buffer = buffer;
+            this.position = offset;
+            this.limit = offset + length;
+        }
+
+        /// <summary>
+        /// Whether all fields of the message have been read
+        /// </summary>
+        public bool IsAtEnd
+        {
+            get { return position >= limit; }
+        }
+
+        /// <summary>
+        /// Reads the number and wire type of the next field, or returns false at the end of the message
+        /// </summary>
+        public bool ReadTag(out int number, out int wireType)
+        {
+            if (IsAtEnd)
+            {
+                number = 0;
+                wireType = 0;
+                return false;
+            }
+            ulong tag = ReadVarint();
+            if (tag >> 3 == 0 || tag >> 3 > int.MaxValue)
+            {
+                throw new FormatException("Invalid field number");
+            }
+            number = (int)(tag >> 3);
+            wireType = (int)(tag & 7);
+            return true;
+        }
+
+        public ulong ReadVarint()
+        {
+            ulong result = 0;
+            for (int shift = 0; shift < 64; shift += 7)
+            {
+                byte b = ReadByte();
+                result |= (ulong)(b & 0x7F) << shift;
+                if ((b & 0x80) == 0)
+                {
+                    return result;
+                }
+            }
+            throw new FormatException("Malformed varint");
+        }
+
+        public int ReadSInt32()
+        {
+            uint value = (uint)ReadVarint();
+            return (int)(value >> 1) ^ -(int)(value & 1);
+        }
+
+        public long ReadSInt64()
+        {
+            ulong value = ReadVarint();
+            return (long)(value >> 1) ^ -(long)(value & 1);
+        }
+
+        public uint ReadFixed32()
+        {
+            Require(4);
+            uint value = buffer[position] | (uint)buffer[position + 1] << 8 | (uint)buffer[position + 2] << 16 | (uint)buffer[position + 3] << 24;
+            position += 4;
+            return value;
+        }
+
+        public ulong ReadFixed64()
+        {
+            ulong low = ReadFixed32();
+            ulong high = ReadFixed32();
+            return low | high << 32;
+        }
+
+        public float ReadFloat()
+        {
+            return BitConverter.ToSingle(BitConverter.GetBytes(ReadFixed32()), 0);
+        }
+
+        public double ReadDouble()
+        {
+            return BitConverter.Int64BitsToDouble((long)ReadFixed64());
+        }
+
+        public string ReadString()
+        {
+            int length = ReadLength();
+            string value = Encoding.UTF8.GetString(buffer, position, length);
+            position += length;
+            return value;
+        }
+
+        public byte[] ReadBytes()
+        {
+            int length = ReadLength();
+            byte[] value = new byte[length];
+            Buffer.BlockCopy(buffer, position, value, 0, length);
+            position += length;
+            return value;
+        }
+
+        /// <summary>
+        /// Returns a reader of the embedded message or packed values of a length-delimited field
+        /// </summary>
+        public ProtoxlsReader ReadMessage()
+        {
+            int length = ReadLength();
+            var reader = new ProtoxlsReader(buffer, position, length);
+            position += length;
+            return reader;
+        }
+
+        /// <summary>
+        /// Skips the value of a field unknown to the generated code
+        /// </summary>
+        public void SkipField(int wireType)
+        {
+            switch (wireType)
+            {
+                case 0:
+                    ReadVarint();
+                    break;
+                case 1:
+                    Require(8);
+                    position += 8;
+                    break;
+                case 2:
+                    int length = ReadLength();
+                    position += length;
+                    break;
+                case 3:
+                    int number, groupWireType;
+                    while (ReadTag(out number, out groupWireType) && groupWireType != 4)
+                    {
+                        SkipField(groupWireType);
+                    }
+                    break;
+                case 5:
+                    Require(4);
+                    position += 4;
+                    break;
+                default:
+                    throw new FormatException("Invalid wire type " + wireType);
+            }
+        }
+
+        private byte ReadByte()
+        {
+            Require(1);
+            return buffer[position++];
+        }
+
+        private int ReadLength()
+        {
+            ulong length = ReadVarint();
+            if (length > (ulong)(limit - position))
+            {
+                throw new FormatException("Truncated message");
+            }
+            return (int)length;
+        }
+
+        private void Require(int count)
+        {
+            if (limit - position < count)
+            {
+                throw new FormatException("Truncated message");
+            }
+        }
+    }
+
+    /// <summary>
+    /// Splits the binary output of a table into rows, in the raw, container, wrapper or indexed layout
+    /// </summary>
+    public static class ProtoxlsRows
+    {
+        private static uint[] crcTable;
+
+        /// <summary>
+        /// Returns a reader per row, in file order
+        /// </summary>
+        public static List<ProtoxlsReader> Read(byte[] data)
+        {
+            if (HasMagic(data, "PXLS"))
+            {
+                return ReadContainer(data);
+            }
+            if (HasMagic(data, "PXLI"))
+            {
+                return ReadIndexed(data);
+            }
+            // Wrapper messages start with the tag of field 1, raw rows with a big-endian length far below 0x0A000000
+            if (data.Length > 0 && data[0] == 0x0A)
+            {
+                return ReadWrapper(data);
+            }
+            return ReadRaw(data);
+        }
+
+        private static List<ProtoxlsReader> ReadRaw(byte[] data)
+        {
+            var rows = new List<ProtoxlsReader>();
+            int position = 0;
+            while (position < data.Length)
+            {
+                int length = (int)ReadUInt32(data, ref position);
+                rows.Add(new ProtoxlsReader(data, position, length));
+                position += length;
+            }
+            return rows;
+        }
+
+        private static List<ProtoxlsReader> ReadWrapper(byte[] data)
+        {
+            var rows = new List<ProtoxlsReader>();
+            var reader = new ProtoxlsReader(data);
+            int number, wireType;
+            while (reader.ReadTag(out number, out wireType))
+            {
+                if (number == 1 && wireType == 2)
+                {
+                    rows.Add(reader.ReadMessage());
+                }
+                else
+                {
+                    reader.SkipField(wireType);
+                }
+            }
+            return rows;
+        }
+
+        private static List<ProtoxlsReader> ReadContainer(byte[] data)
+        {
+            if (data.Length < 8 || Crc32(data, data.Length - 4) != ReadUInt32At(data, data.Length - 4))
+            {
+                throw new FormatException("Container checksum mismatch");
+            }
+            int position = 4;
+            int version = ReadUInt16(data, ref position);
+            if (version != 1)
+            {
+                throw new FormatException("Unsupported container version " + version);
+            }
+            ReadUInt16(data, ref position);
+            SkipString(data, ref position);
+            SkipString(data, ref position);
+            int keyCount = ReadUInt16(data, ref position);
+            for (int i = 0; i < keyCount; i++)
+            {
+                SkipString(data, ref position);
+            }
+            position += 32;
+            uint rowCount = ReadUInt32(data, ref position);
+            int descriptorLength = (int)ReadUInt32(data, ref position);
+            position += descriptorLength;
+
+            var rows = new List<ProtoxlsReader>();
+            for (uint i = 0; i < rowCount; i++)
+            {
+                int length = (int)ReadUInt32(data, ref position);
+                rows.Add(new ProtoxlsReader(data, position, length));
+                position += length;
+            }
+            return rows;
+        }
+
+        private static List<ProtoxlsReader> ReadIndexed(byte[] data)
+        {
+            int position = 4;
+            int version = ReadUInt16(data, ref position);
+            if (version != 1)
+            {
+                throw new FormatException("Unsupported indexed version " + version);
+            }
+            int keyLevels = ReadUInt16(data, ref position);
+            long rowCount = ReadUInt32(data, ref position);
+            position = 16;
+            long indexOffset = (long)ReadUInt64(data, ref position);
+            long entrySize = keyLevels * 9 + 12;
+            if (indexOffset < 0 || indexOffset + rowCount * entrySize > data.Length)
+            {
+                throw new FormatException("Truncated index");
+            }
+
+            // Rows are stored in index order, which is sheet order for rows with equal keys
+            var rows = new List<ProtoxlsReader>();
+            for (long i = 0; i < rowCount; i++)
+            {
+                int entryEnd = (int)(indexOffset + (i + 1) * entrySize);
+                ulong rowOffset = ReadUInt64At(data, entryEnd - 12);
+                uint rowLength = ReadUInt32At(data, entryEnd - 4);
+                if (rowOffset > (ulong)data.Length)
+                {
+                    throw new FormatException("Row out of bounds");
+                }
+                rows.Add(new ProtoxlsReader(data, (int)rowOffset, (int)Math.Min(rowLength, int.MaxValue)));
+            }
+            return rows;
+        }
+
+        private static bool HasMagic(byte[] data, string magic)
+        {
+            if (data.Length < magic.Length)
+            {
+                return false;
+            }
+            for (int i = 0; i < magic.Length; i++)
+            {
+                if (data[i] != magic[i])
+                {
+                    return false;
+                }
+            }
+            return true;
+        }
+
+        private static void SkipString(byte[] data, ref int position)
+        {
+            int length = ReadUInt16(data, ref position);
+            position += length;
+        }
+
+        private static int ReadUInt16(byte[] data, ref int position)
+        {
+            if (position < 0 || position > data.Length - 2)
+            {
+                throw new FormatException("Truncated data");
+            }
+            int value = data[position] << 8 | data[position + 1];
+            position += 2;
+            return value;
+        }
+
+        private static uint ReadUInt32(byte[] data, ref int position)
+        {
+            if (position < 0 || position > data.Length - 4)
+            {
+                throw new FormatException("Truncated data");
+            }
+            uint value = ReadUInt32At(data, position);
+            position += 4;
+            return value;
+        }
+
+        private static ulong ReadUInt64(byte[] data, ref int position)
+        {
+            ulong high = ReadUInt32(data, ref position);
+            ulong low = ReadUInt32(data, ref position);
+            return high << 32 | low;
+        }
+
+        private static uint ReadUInt32At(byte[] data, int position)
+        {
+            return (uint)data[position] << 24 | (uint)data[position + 1] << 16 | (uint)data[position + 2] << 8 | data[position + 3];
+        }
+
+        private static ulong ReadUInt64At(byte[] data, int position)
+        {
+            return (ulong)ReadUInt32At(data, position) << 32 | ReadUInt32At(data, position + 4);
+        }
+
+        private static uint Crc32(byte[] data, int length)
+        {
+            if (crcTable == null)
+            {
+                var table = new uint[256];
+                for (uint i = 0; i < 256; i++)
+                {
+                    uint value = i;
+                    for (int bit = 0; bit < 8; bit++)
+                    {
+                        value = (value & 1) != 0 ? 0xEDB88320 ^ value >> 1 : value >> 1;
+                    }
+                    table[i] = value;
+                }
+                crcTable = table;
+            }
+
+            uint crc = 0xFFFFFFFF;
+            for (int i = 0; i < length; i++)
+            {
+                crc = crcTable[(crc ^ data[i]) & 0xFF] ^ crc >> 8;
+            }
+            return ~crc;
+        }
+    }
+}
+`
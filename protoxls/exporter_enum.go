@@ -0,0 +1,344 @@
+package protoxls
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// EnumFileName is the base name of the enum definitions file of each format
+	EnumFileName = "enums"
+
+	// PhpEnumStyleClass writes each enum as a final class with constants
+	PhpEnumStyleClass = "class"
+	// PhpEnumStyleEnum writes each enum as a PHP 8.1 backed enum
+	PhpEnumStyleEnum = "enum"
+
+	// EnumFormatNumber exports enum fields as their numbers
+	EnumFormatNumber = "number"
+	// EnumFormatName exports enum fields as the proto names of their values
+	EnumFormatName = "name"
+	// EnumFormatAlias exports enum fields as the (alias) of their values, or the name if no alias is set
+	EnumFormatAlias = "alias"
+)
+
+// IsValidEnumFormat checks if a string is one of the enum export formats
+func IsValidEnumFormat(format string) bool {
+	return format == EnumFormatNumber || format == EnumFormatName || format == EnumFormatAlias
+}
+
+// getEnumFormat returns the (enum_format) option of a field, or the run-wide format if it is not set
+func getEnumFormat(field *desc.FieldDescriptor, defaultFormat string) string {
+	if opts := field.GetFieldOptions(); opts != nil {
+		if ext, ok := proto.GetExtension(opts, E_EnumFormat).(string); ok && ext != "" {
+			return ext
+		}
+	}
+	if defaultFormat == "" {
+		return EnumFormatNumber
+	}
+	return defaultFormat
+}
+
+// convertEnumValue converts an enum number to the value exported for the field, which is the number itself
+// or a string. Numbers without a defined value are always exported as numbers
+func convertEnumValue(number int32, field *desc.FieldDescriptor, defaultFormat string) interface{} {
+	enumVal := field.GetEnumType().FindValueByNumber(number)
+	if enumVal == nil {
+		return number
+	}
+	switch getEnumFormat(field, defaultFormat) {
+	case EnumFormatName:
+		return enumVal.GetName()
+	case EnumFormatAlias:
+		return getEnumDisplayName(enumVal)
+	}
+	return number
+}
+
+// checkEnumFormats checks the (enum_format) options of the fields of a message and its nested messages
+func checkEnumFormats(msgDesc *desc.MessageDescriptor) error {
+	checked := make(map[string]bool)
+	var check func(msgDesc *desc.MessageDescriptor) error
+	check = func(msgDesc *desc.MessageDescriptor) error {
+		if checked[msgDesc.GetFullyQualifiedName()] {
+			return nil
+		}
+		checked[msgDesc.GetFullyQualifiedName()] = true
+
+		for _, field := range msgDesc.GetFields() {
+			if format := getEnumFormat(field, EnumFormatNumber); !IsValidEnumFormat(format) {
+				return fmt.Errorf("field %s has unknown enum_format %q, use number, name or alias", field.GetFullyQualifiedName(), format)
+			}
+			if field.GetMessageType() != nil {
+				if err := check(field.GetMessageType()); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return check(msgDesc)
+}
+
+// CollectEnums returns the enums used by the fields of the tables, including nested messages,
+// in order of first use
+func CollectEnums(stores []*TableStore) []*desc.EnumDescriptor {
+	var enums []*desc.EnumDescriptor
+	seen := make(map[string]bool)
+
+	var visit func(msgDesc *desc.MessageDescriptor)
+	visit = func(msgDesc *desc.MessageDescriptor) {
+		if seen[msgDesc.GetFullyQualifiedName()] {
+			return
+		}
+		seen[msgDesc.GetFullyQualifiedName()] = true
+
+		for _, field := range msgDesc.GetFields() {
+			valueField := field
+			if field.IsMap() {
+				valueField = field.GetMapValueType()
+			}
+			if enumDesc := valueField.GetEnumType(); enumDesc != nil && !seen[enumDesc.GetFullyQualifiedName()] {
+				seen[enumDesc.GetFullyQualifiedName()] = true
+				enums = append(enums, enumDesc)
+			}
+			if valueField.GetMessageType() != nil {
+				visit(valueField.GetMessageType())
+			}
+		}
+	}
+
+	for _, store := range stores {
+		visit(store.GetMessageDescriptor())
+	}
+	return enums
+}
+
+// enumIdentifier returns an identifier for an enum, nested and packaged enums are joined with underscores
+func enumIdentifier(enumDesc *desc.EnumDescriptor) string {
+	return strings.ReplaceAll(enumDesc.GetFullyQualifiedName(), ".", "_")
+}
+
+// enumAliasesByNumber returns the display name of each enum number, the first value wins for aliased numbers
+func enumAliasesByNumber(enumDesc *desc.EnumDescriptor) ([]int32, map[int32]string) {
+	var numbers []int32
+	aliases := make(map[int32]string)
+	displayNames := getEnumDisplayNames(enumDesc)
+	for i, enumVal := range enumDesc.GetValues() {
+		if _, exists := aliases[enumVal.GetNumber()]; exists {
+			continue
+		}
+		numbers = append(numbers, enumVal.GetNumber())
+		aliases[enumVal.GetNumber()] = displayNames[i]
+	}
+	return numbers, aliases
+}
+
+// ExportEnums exports the enums as a Lua table of values and a table of display names per enum
+func (le *LuaExporter) ExportEnums(enums []*desc.EnumDescriptor) error {
+	file, err := CreateNamedOutputFile(EnumFileName, le.OutputDir, "Lua")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var result strings.Builder
+	if le.ReadOnly {
+		result.WriteString(luaReadOnlyHelper)
+	}
+	if le.ModuleFormat {
+		result.WriteString(fmt.Sprintf("local %s = {}\n", EnumFileName))
+	} else {
+		result.WriteString(fmt.Sprintf("%s = {}\n", EnumFileName))
+	}
+
+	for _, enumDesc := range enums {
+		name := enumIdentifier(enumDesc)
+		result.WriteString("\n")
+		if comment := getDescriptorComment(enumDesc); comment != "" {
+			for _, line := range strings.Split(comment, "\n") {
+				result.WriteString("-- " + strings.TrimSpace(line) + "\n")
+			}
+		}
+
+		result.WriteString(fmt.Sprintf("%s.%s = {\n", EnumFileName, name))
+		for _, enumVal := range enumDesc.GetValues() {
+			result.WriteString(fmt.Sprintf("    %s = %d,\n", formatLuaName(enumVal.GetName()), enumVal.GetNumber()))
+		}
+		result.WriteString("}\n")
+
+		numbers, aliases := enumAliasesByNumber(enumDesc)
+		result.WriteString(fmt.Sprintf("%s.%sAlias = {\n", EnumFileName, name))
+		for _, number := range numbers {
+			result.WriteString(fmt.Sprintf("    [%d] = %s,\n", number, quoteLuaString(aliases[number])))
+		}
+		result.WriteString("}\n")
+	}
+
+	result.WriteString(strings.TrimPrefix(le.generateLuaFooter(EnumFileName), "\n"))
+	if _, err := file.WriteString(result.String()); err != nil {
+		return fmt.Errorf("failed to write lua enums: %v", err)
+	}
+	return nil
+}
+
+// ExportEnums exports the enums as a JSON object holding the values and display names of each enum
+func (je *JsonExporter) ExportEnums(enums []*desc.EnumDescriptor) error {
+	result := NewOrderedMap()
+	for _, enumDesc := range enums {
+		values := NewOrderedMap()
+		for _, enumVal := range enumDesc.GetValues() {
+			values.Set(enumVal.GetName(), enumVal.GetNumber())
+		}
+		aliases := NewOrderedMap()
+		numbers, displayNames := enumAliasesByNumber(enumDesc)
+		for _, number := range numbers {
+			aliases.Set(strconv.Itoa(int(number)), displayNames[number])
+		}
+
+		definition := NewOrderedMap()
+		definition.Set("values", values)
+		definition.Set("aliases", aliases)
+		result.Set(enumDesc.GetFullyQualifiedName(), definition)
+	}
+
+	var jsonBytes []byte
+	var err error
+	if je.CompactFormat {
+		jsonBytes, err = json.Marshal(result)
+	} else {
+		jsonBytes, err = json.MarshalIndent(result, "", "    ")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON enums: %v", err)
+	}
+
+	file, err := CreateNamedOutputFile(EnumFileName, je.OutputDir, "JSON")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.Write(jsonBytes); err != nil {
+		return fmt.Errorf("failed to write JSON enums: %v", err)
+	}
+	return nil
+}
+
+// ExportEnums exports the enums as a YAML mapping holding the values and display names of each enum
+func (e *YamlExporter) ExportEnums(enums []*desc.EnumDescriptor) error {
+	result := &yaml.Node{Kind: yaml.MappingNode}
+	for _, enumDesc := range enums {
+		values := &yaml.Node{Kind: yaml.MappingNode}
+		for _, enumVal := range enumDesc.GetValues() {
+			values.Content = append(values.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Value: enumVal.GetName()},
+				e.convertValueToYamlNode(enumVal.GetNumber()))
+		}
+		aliases := &yaml.Node{Kind: yaml.MappingNode}
+		numbers, displayNames := enumAliasesByNumber(enumDesc)
+		for _, number := range numbers {
+			aliases.Content = append(aliases.Content,
+				e.convertValueToYamlNode(number),
+				e.convertValueToYamlNode(displayNames[number]))
+		}
+
+		definition := &yaml.Node{Kind: yaml.MappingNode}
+		definition.Content = append(definition.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: "values"}, values,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: "aliases"}, aliases)
+		result.Content = append(result.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: enumDesc.GetFullyQualifiedName()}, definition)
+	}
+
+	yamlData, err := yaml.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal enums to YAML: %v", err)
+	}
+
+	file, err := CreateNamedOutputFile(EnumFileName, e.OutputDir, "yaml")
+	if err != nil {
+		return fmt.Errorf("failed to create YAML file: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(yamlData); err != nil {
+		return fmt.Errorf("failed to write YAML enums: %v", err)
+	}
+	return nil
+}
+
+// ExportEnums exports the enums as PHP classes with constants, or as PHP 8.1 backed enums
+func (e *PhpExporter) ExportEnums(enums []*desc.EnumDescriptor) error {
+	file, err := CreateNamedOutputFile(EnumFileName, e.OutputDir, "php")
+	if err != nil {
+		return fmt.Errorf("failed to create PHP file: %v", err)
+	}
+	defer file.Close()
+
+	var result strings.Builder
+	result.WriteString("<?php\n")
+	for _, enumDesc := range enums {
+		result.WriteString("\n")
+		if comment := getDescriptorComment(enumDesc); comment != "" {
+			for _, line := range strings.Split(comment, "\n") {
+				result.WriteString("// " + strings.TrimSpace(line) + "\n")
+			}
+		}
+		if e.EnumStyle == PhpEnumStyleEnum {
+			e.writePhpBackedEnum(&result, enumDesc)
+		} else {
+			e.writePhpEnumClass(&result, enumDesc)
+		}
+	}
+
+	if _, err := file.WriteString(result.String()); err != nil {
+		return fmt.Errorf("failed to write PHP enums: %v", err)
+	}
+	return nil
+}
+
+// writePhpEnumClass writes an enum as a class with one constant per value and an ALIASES map
+func (e *PhpExporter) writePhpEnumClass(result *strings.Builder, enumDesc *desc.EnumDescriptor) {
+	result.WriteString(fmt.Sprintf("final class %s\n{\n", enumIdentifier(enumDesc)))
+	for _, enumVal := range enumDesc.GetValues() {
+		result.WriteString(fmt.Sprintf("    const %s = %d;\n", enumVal.GetName(), enumVal.GetNumber()))
+	}
+
+	result.WriteString("\n    const ALIASES = [\n")
+	numbers, aliases := enumAliasesByNumber(enumDesc)
+	for _, number := range numbers {
+		result.WriteString(fmt.Sprintf("        %d => %s,\n", number, quotePhpString(aliases[number])))
+	}
+	result.WriteString("    ];\n}\n")
+}
+
+// writePhpBackedEnum writes an enum as an int backed enum with an alias() method.
+// Backed enum values must be unique, so aliased numbers become constants referring to the first case
+func (e *PhpExporter) writePhpBackedEnum(result *strings.Builder, enumDesc *desc.EnumDescriptor) {
+	result.WriteString(fmt.Sprintf("enum %s: int\n{\n", enumIdentifier(enumDesc)))
+
+	caseNames := make(map[int32]string)
+	for _, enumVal := range enumDesc.GetValues() {
+		if caseName, exists := caseNames[enumVal.GetNumber()]; exists {
+			result.WriteString(fmt.Sprintf("    const %s = self::%s;\n", enumVal.GetName(), caseName))
+			continue
+		}
+		caseNames[enumVal.GetNumber()] = enumVal.GetName()
+		result.WriteString(fmt.Sprintf("    case %s = %d;\n", enumVal.GetName(), enumVal.GetNumber()))
+	}
+
+	result.WriteString("\n    public function alias(): string\n    {\n        return match ($this) {\n")
+	numbers, aliases := enumAliasesByNumber(enumDesc)
+	for _, number := range numbers {
+		result.WriteString(fmt.Sprintf("            self::%s => %s,\n", caseNames[number], quotePhpString(aliases[number])))
+	}
+	result.WriteString("        };\n    }\n}\n")
+}
@@ -0,0 +1,689 @@
+package protoxls
+
+import (
+	"fmt"
+	"go/format"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+)
+
+const (
+	// GoLoaderJSON generates loaders reading the JSON output with encoding/json
+	GoLoaderJSON = "json"
+	// GoLoaderBinary generates loaders reading the binary output without dependencies
+	GoLoaderBinary = "bin"
+
+	// GoRuntimeFileName is the base name of the file holding the helpers shared by all loaders
+	GoRuntimeFileName = "protoxls"
+	// goDefaultPackage is the package name used if the output directory is no valid package name
+	goDefaultPackage = "config"
+)
+
+// IsValidGoLoader checks if a string is one of the outputs Go loaders can read
+func IsValidGoLoader(loader string) bool {
+	return loader == GoLoaderJSON || loader == GoLoaderBinary
+}
+
+// GoExporter generates Go structs and enums for the tables, and a loader per table building map
+// lookups along the (keys) hierarchy from the JSON or binary output, optionally embedded in the package
+type GoExporter struct {
+	OutputDir  string // Custom output directory, defaults to DefaultOutputDir if empty
+	Package    string // Package name of the generated code, the base name of the output directory if empty
+	Loader     string // Output read by the loaders, GoLoaderJSON if empty
+	Embed      bool   // Whether to write the data next to the code and embed it with go:embed
+	JsonStyle  string // JSON mapping style of the JSON output, JsonStyleDefault if empty
+	EnumFormat string // How the JSON output exports enum fields, EnumFormatNumber if empty
+
+	// Types already written for a previous table, each type is defined once per package
+	writtenTypes   map[string]bool
+	runtimeWritten bool
+}
+
+// ExportResult generates the loader of the table and the types it uses that no previous table used
+func (ge *GoExporter) ExportResult(store *TableStore) error {
+	packageName, err := ge.packageName()
+	if err != nil {
+		return err
+	}
+	if ge.writtenTypes == nil {
+		ge.writtenTypes = make(map[string]bool)
+	}
+	if !ge.runtimeWritten {
+		if err := ge.writeRuntime(packageName); err != nil {
+			return err
+		}
+		ge.runtimeWritten = true
+	}
+
+	keyFields, err := getTableKeyFields(store)
+	if err != nil {
+		return err
+	}
+
+	// go:embed only reads files inside the package directory
+	if ge.Embed {
+		var dataExporter Exporter = &JsonExporter{OutputDir: ge.OutputDir, CompactFormat: true, Style: ge.JsonStyle, EnumFormat: ge.EnumFormat}
+		if ge.Loader == GoLoaderBinary {
+			dataExporter = &BinExporter{OutputDir: ge.OutputDir, Format: BinFormatRaw}
+		}
+		if err := dataExporter.ExportResult(store); err != nil {
+			return err
+		}
+	}
+
+	msgDesc := store.GetMessageDescriptor()
+	var code strings.Builder
+	code.WriteString(fmt.Sprintf("// Code generated by protoxls from %s. DO NOT EDIT.\n\n", msgDesc.GetFile().GetName()))
+	code.WriteString(fmt.Sprintf("package %s\n\n", packageName))
+	code.WriteString("import (\n")
+	if ge.Embed {
+		code.WriteString("_ \"embed\"\n")
+	}
+	if ge.Loader != GoLoaderBinary {
+		code.WriteString("\"encoding/json\"\n")
+	}
+	code.WriteString("\"fmt\"\n")
+	if ge.Embed {
+		code.WriteString("\"sync\"\n")
+	}
+	code.WriteString(")\n")
+
+	for _, typeDesc := range ge.collectNewTypes(msgDesc) {
+		if enumDesc, ok := typeDesc.(*desc.EnumDescriptor); ok {
+			ge.writeEnum(&code, enumDesc)
+		} else {
+			ge.writeMessage(&code, typeDesc.(*desc.MessageDescriptor))
+		}
+	}
+	ge.writeTable(&code, store, keyFields)
+
+	// gofmt aligns the fields and comments, so the generated code is written without caring for layout
+	source, err := format.Source([]byte(code.String()))
+	if err != nil {
+		return fmt.Errorf("failed to format Go code of %s: %v", msgDesc.GetName(), err)
+	}
+
+	file, err := CreateOutputFile(store, ge.OutputDir, "Go")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if _, err := file.Write(source); err != nil {
+		return fmt.Errorf("failed to write Go code: %v", err)
+	}
+	return nil
+}
+
+// packageName returns the configured package name, or one derived from the output directory
+func (ge *GoExporter) packageName() (string, error) {
+	if ge.Package != "" {
+		if !token.IsIdentifier(ge.Package) {
+			return "", fmt.Errorf("invalid Go package name %q", ge.Package)
+		}
+		return ge.Package, nil
+	}
+
+	outputDir := ge.OutputDir
+	if outputDir == "" {
+		outputDir = DefaultOutputDir
+	}
+	if absDir, err := filepath.Abs(outputDir); err == nil {
+		outputDir = absDir
+	}
+	name := strings.ToLower(filepath.Base(outputDir))
+	if !token.IsIdentifier(name) {
+		return goDefaultPackage, nil
+	}
+	return name, nil
+}
+
+// collectNewTypes returns the messages and enums used by a row message that are not written yet, the row
+// message first. The nested types of a message are written with it, as protoc-gen-go does
+func (ge *GoExporter) collectNewTypes(msgDesc *desc.MessageDescriptor) []desc.Descriptor {
+	var types []desc.Descriptor
+
+	var visitType func(typeDesc desc.Descriptor)
+	visitType = func(typeDesc desc.Descriptor) {
+		if ge.writtenTypes[typeDesc.GetFullyQualifiedName()] {
+			return
+		}
+		ge.writtenTypes[typeDesc.GetFullyQualifiedName()] = true
+		types = append(types, typeDesc)
+
+		msgDesc, ok := typeDesc.(*desc.MessageDescriptor)
+		if !ok {
+			return
+		}
+		for _, field := range msgDesc.GetFields() {
+			if field.IsMap() {
+				field = field.GetMapValueType()
+			}
+			if field.GetMessageType() != nil {
+				visitType(field.GetMessageType())
+			}
+			if field.GetEnumType() != nil {
+				visitType(field.GetEnumType())
+			}
+		}
+		for _, nested := range msgDesc.GetNestedMessageTypes() {
+			if !nested.IsMapEntry() {
+				visitType(nested)
+			}
+		}
+		for _, nested := range msgDesc.GetNestedEnumTypes() {
+			visitType(nested)
+		}
+	}
+
+	visitType(msgDesc)
+	return types
+}
+
+// writeMessage writes the struct of a message and the method decoding it from the loaded output
+func (ge *GoExporter) writeMessage(code *strings.Builder, msgDesc *desc.MessageDescriptor) {
+	typeName := goTypeName(msgDesc)
+	code.WriteString("\n")
+	writeGoComment(code, getDescriptorComment(msgDesc))
+	code.WriteString(fmt.Sprintf("type %s struct {\n", typeName))
+	for _, field := range getSortedFields(msgDesc) {
+		writeGoComment(code, strings.Join(getFieldDescription(field), "\n"))
+		code.WriteString(fmt.Sprintf("%s %s", goFieldName(field), ge.fieldType(field)))
+		if ge.Loader != GoLoaderBinary {
+			code.WriteString(fmt.Sprintf(" `json:%s`", strconv.Quote(ge.jsonFieldName(field))))
+		}
+		code.WriteString("\n")
+	}
+	code.WriteString("}\n")
+
+	if ge.Loader == GoLoaderBinary {
+		ge.writeUnmarshal(code, msgDesc)
+	} else {
+		ge.writeUnmarshalJSON(code, msgDesc)
+	}
+}
+
+// writeEnum writes an enum type with a constant per value, the name and value maps of protoc-gen-go,
+// and in JSON mode a method reading the value from its number or name
+func (ge *GoExporter) writeEnum(code *strings.Builder, enumDesc *desc.EnumDescriptor) {
+	typeName := goTypeName(enumDesc)
+	code.WriteString("\n")
+	writeGoComment(code, getDescriptorComment(enumDesc))
+	code.WriteString(fmt.Sprintf("type %s int32\n\nconst (\n", typeName))
+	for _, enumVal := range enumDesc.GetValues() {
+		var description []string
+		if displayName := getEnumDisplayName(enumVal); displayName != enumVal.GetName() {
+			description = append(description, displayName)
+		}
+		if comment := getDescriptorComment(enumVal); comment != "" {
+			description = append(description, comment)
+		}
+		writeGoComment(code, strings.Join(description, "\n"))
+		code.WriteString(fmt.Sprintf("%s %s = %d\n", goEnumValueName(enumVal), typeName, enumVal.GetNumber()))
+	}
+	code.WriteString(")\n\n")
+
+	// Values sharing a number (allow_alias) are named after the first one
+	code.WriteString(fmt.Sprintf("// %s_name maps the numbers of %s to their names\n", typeName, typeName))
+	code.WriteString(fmt.Sprintf("var %s_name = map[int32]string{\n", typeName))
+	named := make(map[int32]bool)
+	for _, enumVal := range enumDesc.GetValues() {
+		if !named[enumVal.GetNumber()] {
+			named[enumVal.GetNumber()] = true
+			code.WriteString(fmt.Sprintf("%d: %s,\n", enumVal.GetNumber(), strconv.Quote(enumVal.GetName())))
+		}
+	}
+	code.WriteString("}\n\n")
+	code.WriteString(fmt.Sprintf("// %s_value maps the names of %s to their numbers\n", typeName, typeName))
+	code.WriteString(fmt.Sprintf("var %s_value = map[string]int32{\n", typeName))
+	for _, enumVal := range enumDesc.GetValues() {
+		code.WriteString(fmt.Sprintf("%s: %d,\n", strconv.Quote(enumVal.GetName()), enumVal.GetNumber()))
+	}
+	code.WriteString("}\n\n")
+
+	code.WriteString("// String returns the name of the value, or its number if it has none\n")
+	code.WriteString(fmt.Sprintf("func (x %s) String() string {\n", typeName))
+	code.WriteString(fmt.Sprintf("return protoxlsEnumString(%s_name, int32(x))\n}\n", typeName))
+
+	if ge.Loader != GoLoaderBinary {
+		code.WriteString("\n// UnmarshalJSON reads the value from its number or name\n")
+		code.WriteString(fmt.Sprintf("func (x *%s) UnmarshalJSON(data []byte) error {\n", typeName))
+		code.WriteString(fmt.Sprintf("value, err := protoxlsUnmarshalEnum(data, %s_value, %s)\n", typeName, strconv.Quote(typeName)))
+		code.WriteString("if err != nil {\nreturn err\n}\n")
+		code.WriteString(fmt.Sprintf("*x = %s(value)\nreturn nil\n}\n", typeName))
+	}
+}
+
+// writeUnmarshal writes the method decoding the fields of a message from the binary output
+func (ge *GoExporter) writeUnmarshal(code *strings.Builder, msgDesc *desc.MessageDescriptor) {
+	code.WriteString(fmt.Sprintf("\nfunc (m *%s) unmarshal(d *protoxlsDecoder) {\n", goTypeName(msgDesc)))
+	code.WriteString("for d.next() {\nswitch d.num {\n")
+	for _, field := range getSortedFields(msgDesc) {
+		name := "m." + goFieldName(field)
+		code.WriteString(fmt.Sprintf("case %d:\n", field.GetNumber()))
+		switch {
+		case field.IsMap():
+			keyField, valueField := field.GetMapKeyType(), field.GetMapValueType()
+			code.WriteString(fmt.Sprintf("var key %s\n", ge.elementType(keyField)))
+			if valueType := valueField.GetMessageType(); valueType != nil {
+				code.WriteString(fmt.Sprintf("value := &%s{}\n", goTypeName(valueType)))
+			} else {
+				code.WriteString(fmt.Sprintf("var value %s\n", ge.elementType(valueField)))
+			}
+			code.WriteString("d.message(func(d *protoxlsDecoder) {\nfor d.next() {\nswitch d.num {\ncase 1:\n")
+			code.WriteString(fmt.Sprintf("key = %s\ncase 2:\n", ge.readValue(keyField)))
+			if valueField.GetMessageType() != nil {
+				code.WriteString("d.message(value.unmarshal)\n")
+			} else {
+				code.WriteString(fmt.Sprintf("value = %s\n", ge.readValue(valueField)))
+			}
+			code.WriteString("default:\nd.skip()\n}\n}\n})\n")
+			code.WriteString(fmt.Sprintf("if %s == nil {\n%s = make(%s)\n}\n", name, name, ge.fieldType(field)))
+			code.WriteString(fmt.Sprintf("%s[key] = value\n", name))
+		case field.GetMessageType() != nil && field.IsRepeated():
+			code.WriteString(fmt.Sprintf("item := &%s{}\n", goTypeName(field.GetMessageType())))
+			code.WriteString("d.message(item.unmarshal)\n")
+			code.WriteString(fmt.Sprintf("%s = append(%s, item)\n", name, name))
+		case field.GetMessageType() != nil:
+			code.WriteString(fmt.Sprintf("if %s == nil {\n%s = &%s{}\n}\n", name, name, goTypeName(field.GetMessageType())))
+			code.WriteString(fmt.Sprintf("d.message(%s.unmarshal)\n", name))
+		case field.IsRepeated() && isPackableField(field):
+			// Repeated numbers are packed by proto3 writers, but unpacked values must be accepted as well
+			code.WriteString("if d.typ == protoxlsWireBytes {\n")
+			code.WriteString(fmt.Sprintf("d.packed(func(d *protoxlsDecoder) {\n%s = append(%s, %s)\n})\n", name, name, ge.readValue(field)))
+			code.WriteString(fmt.Sprintf("} else {\n%s = append(%s, %s)\n}\n", name, name, ge.readValue(field)))
+		case field.IsRepeated():
+			code.WriteString(fmt.Sprintf("%s = append(%s, %s)\n", name, name, ge.readValue(field)))
+		default:
+			code.WriteString(fmt.Sprintf("%s = %s\n", name, ge.readValue(field)))
+		}
+	}
+	code.WriteString("default:\nd.skip()\n}\n}\n}\n")
+}
+
+// writeUnmarshalJSON writes a method reading the fields encoding/json cannot read by itself: floats
+// written as "NaN" or "Infinity", and 64-bit integers, which protojson writes as strings. The fields
+// are read into lenient types that shadow them, the others into the struct itself
+func (ge *GoExporter) writeUnmarshalJSON(code *strings.Builder, msgDesc *desc.MessageDescriptor) {
+	var lenientFields []*desc.FieldDescriptor
+	for _, field := range getSortedFields(msgDesc) {
+		valueField := field
+		if field.IsMap() {
+			valueField = field.GetMapValueType()
+		}
+		if ge.lenientType(valueField) != "" {
+			lenientFields = append(lenientFields, field)
+		}
+	}
+	if len(lenientFields) == 0 {
+		return
+	}
+
+	typeName := goTypeName(msgDesc)
+	code.WriteString("\n// UnmarshalJSON reads the message with non-finite floats and quoted 64-bit integers\n")
+	code.WriteString(fmt.Sprintf("func (m *%s) UnmarshalJSON(data []byte) error {\n", typeName))
+	code.WriteString(fmt.Sprintf("type plain %s\nlenient := struct {\n*plain\n", typeName))
+	for _, field := range lenientFields {
+		var lenientType string
+		switch {
+		case field.IsMap():
+			lenientType = fmt.Sprintf("map[%s]%s", ge.elementType(field.GetMapKeyType()), ge.lenientType(field.GetMapValueType()))
+		case field.IsRepeated():
+			lenientType = "[]" + ge.lenientType(field)
+		default:
+			lenientType = ge.lenientType(field)
+		}
+		code.WriteString(fmt.Sprintf("%s %s `json:%s`\n", goFieldName(field), lenientType, strconv.Quote(ge.jsonFieldName(field))))
+	}
+	code.WriteString("}{plain: (*plain)(m)}\n")
+	code.WriteString("if err := json.Unmarshal(data, &lenient); err != nil {\nreturn err\n}\n")
+
+	for _, field := range lenientFields {
+		name := goFieldName(field)
+		switch {
+		case field.IsMap():
+			valueType := ge.elementType(field.GetMapValueType())
+			code.WriteString(fmt.Sprintf("if lenient.%s != nil {\nm.%s = make(%s, len(lenient.%s))\n", name, name, ge.fieldType(field), name))
+			code.WriteString(fmt.Sprintf("for key, value := range lenient.%s {\nm.%s[key] = %s(value)\n}\n}\n", name, name, valueType))
+		case field.IsRepeated():
+			elementType := ge.elementType(field)
+			code.WriteString(fmt.Sprintf("if lenient.%s != nil {\nm.%s = make(%s, len(lenient.%s))\n", name, name, ge.fieldType(field), name))
+			code.WriteString(fmt.Sprintf("for i, value := range lenient.%s {\nm.%s[i] = %s(value)\n}\n}\n", name, name, elementType))
+		default:
+			code.WriteString(fmt.Sprintf("m.%s = %s(lenient.%s)\n", name, ge.elementType(field), name))
+		}
+	}
+	code.WriteString("return nil\n}\n")
+}
+
+// lenientType returns the runtime type reading a value of a field encoding/json cannot read, empty for the others
+func (ge *GoExporter) lenientType(field *desc.FieldDescriptor) string {
+	switch field.GetType().String() {
+	case "TYPE_FLOAT", "TYPE_DOUBLE":
+		return "protoxlsFloat"
+	case "TYPE_INT64", "TYPE_SINT64", "TYPE_SFIXED64":
+		if ge.JsonStyle == JsonStyleProtoJSON {
+			return "protoxlsInt"
+		}
+	case "TYPE_UINT64", "TYPE_FIXED64":
+		if ge.JsonStyle == JsonStyleProtoJSON {
+			return "protoxlsUint"
+		}
+	}
+	return ""
+}
+
+// writeTable writes the table type of a store with its loader and lookup, and the embedded data
+func (ge *GoExporter) writeTable(code *strings.Builder, store *TableStore, keyFields []*desc.FieldDescriptor) {
+	msgDesc := store.GetMessageDescriptor()
+	rowType := goTypeName(msgDesc)
+	tableType := rowType + "Table"
+
+	fileName := GetTableName(store) + ".json"
+	if ge.Loader == GoLoaderBinary {
+		fileName = GetTableName(store) + ".bin"
+	}
+
+	// Types of the nested maps, levelTypes[i] holds the rows below the i-th key
+	levelTypes := make([]string, len(keyFields)+1)
+	levelTypes[len(keyFields)] = "*" + rowType
+	for i := len(keyFields) - 1; i >= 0; i-- {
+		levelTypes[i] = fmt.Sprintf("map[%s]%s", ge.elementType(keyFields[i]), levelTypes[i+1])
+	}
+	rowsType := levelTypes[0]
+	if len(keyFields) == 0 {
+		rowsType = "[]*" + rowType
+	}
+
+	keyNames := make([]string, len(keyFields))
+	var params, args []string
+	for i, keyField := range keyFields {
+		keyNames[i] = keyField.GetName()
+		param := goParameterName(keyField)
+		params = append(params, fmt.Sprintf("%s %s", param, ge.elementType(keyField)))
+		args = append(args, param)
+	}
+
+	description := fmt.Sprintf("the rows of %s", GetTableName(store))
+	if len(keyFields) > 0 {
+		description += " by " + strings.Join(keyNames, ", ")
+	} else {
+		description += " in sheet order"
+	}
+
+	code.WriteString(fmt.Sprintf("\n// %sFileName is the name of the exported file %s is loaded from\n", rowType, tableType))
+	code.WriteString(fmt.Sprintf("const %sFileName = %s\n", rowType, strconv.Quote(fileName)))
+	code.WriteString(fmt.Sprintf("\n// %s holds %s\n", tableType, description))
+	code.WriteString(fmt.Sprintf("type %s struct {\nRows %s\n}\n", tableType, rowsType))
+
+	code.WriteString(fmt.Sprintf("\n// Load%s loads the table from the contents of %s\n", tableType, fileName))
+	code.WriteString(fmt.Sprintf("func Load%s(data []byte) (*%s, error) {\n", tableType, tableType))
+	if ge.Loader == GoLoaderBinary {
+		code.WriteString("rows, err := protoxlsRows(data)\nif err != nil {\n")
+		code.WriteString(fmt.Sprintf("return nil, fmt.Errorf(\"failed to load %%s: %%v\", %sFileName, err)\n}\n", rowType))
+		code.WriteString(fmt.Sprintf("table := &%s{", tableType))
+		if len(keyFields) > 0 {
+			code.WriteString(fmt.Sprintf("Rows: make(%s)", rowsType))
+		}
+		code.WriteString("}\n")
+		code.WriteString("for i, rowData := range rows {\n")
+		code.WriteString(fmt.Sprintf("row := &%s{}\n", rowType))
+		code.WriteString("if err := protoxlsUnmarshal(rowData, row.unmarshal); err != nil {\n")
+		code.WriteString(fmt.Sprintf("return nil, fmt.Errorf(\"failed to load row %%d of %%s: %%v\", i+1, %sFileName, err)\n}\n", rowType))
+		code.WriteString("table.add(row)\n}\nreturn table, nil\n}\n")
+		ge.writeAdd(code, tableType, rowType, keyFields, levelTypes)
+	} else {
+		code.WriteString(fmt.Sprintf("table := &%s{}\n", tableType))
+		code.WriteString("if err := json.Unmarshal(data, &table.Rows); err != nil {\n")
+		code.WriteString(fmt.Sprintf("return nil, fmt.Errorf(\"failed to load %%s: %%v\", %sFileName, err)\n}\n", rowType))
+		if len(keyFields) > 0 {
+			code.WriteString(fmt.Sprintf("if table.Rows == nil {\ntable.Rows = make(%s)\n}\n", rowsType))
+		}
+		code.WriteString("return table, nil\n}\n")
+	}
+
+	lookup := ""
+	if len(keyFields) > 0 {
+		lookup = fmt.Sprintf("Rows[%s]", strings.Join(args, "]["))
+		code.WriteString(fmt.Sprintf("\n// Get returns the row with the given %s, or nil if there is none\n", strings.Join(keyNames, ", ")))
+		code.WriteString(fmt.Sprintf("func (t *%s) Get(%s) *%s {\nreturn t.%s\n}\n", tableType, strings.Join(params, ", "), rowType, lookup))
+	}
+
+	if ge.Embed {
+		varPrefix := strings.ToLower(rowType[:1]) + rowType[1:]
+		embedPattern := fileName
+		if strings.ContainsAny(fileName, " \"'`") {
+			embedPattern = strconv.Quote(fileName)
+		}
+		code.WriteString(fmt.Sprintf("\n//go:embed %s\nvar %sData []byte\n\n", embedPattern, varPrefix))
+		code.WriteString(fmt.Sprintf("var (\n%sOnce sync.Once\n%sTable *%s\n)\n", varPrefix, varPrefix, tableType))
+
+		code.WriteString(fmt.Sprintf("\n// Embedded%s returns %s embedded in the package, loaded on first use\n", tableType, description))
+		code.WriteString(fmt.Sprintf("func Embedded%s() *%s {\n", tableType, tableType))
+		code.WriteString(fmt.Sprintf("%sOnce.Do(func() {\n", varPrefix))
+		code.WriteString(fmt.Sprintf("table, err := Load%s(%sData)\n", tableType, varPrefix))
+		code.WriteString("if err != nil {\n// The data is generated with the code, so it only fails to load if it was edited by hand\npanic(err)\n}\n")
+		code.WriteString(fmt.Sprintf("%sTable = table\n})\nreturn %sTable\n}\n", varPrefix, varPrefix))
+
+		if len(keyFields) > 0 {
+			code.WriteString(fmt.Sprintf("\n// Get%s returns the embedded row with the given %s, or nil if there is none\n", rowType, strings.Join(keyNames, ", ")))
+			code.WriteString(fmt.Sprintf("func Get%s(%s) *%s {\nreturn Embedded%s().%s\n}\n", rowType, strings.Join(params, ", "), rowType, tableType, lookup))
+		}
+	}
+}
+
+// writeAdd writes the method adding a decoded row to the (keys) hierarchy. Like the JSON output,
+// the first row of each key path is kept
+func (ge *GoExporter) writeAdd(code *strings.Builder, tableType, rowType string, keyFields []*desc.FieldDescriptor, levelTypes []string) {
+	code.WriteString(fmt.Sprintf("\nfunc (t *%s) add(row *%s) {\n", tableType, rowType))
+	if len(keyFields) == 0 {
+		code.WriteString("t.Rows = append(t.Rows, row)\n}\n")
+		return
+	}
+
+	level := "t.Rows"
+	for i := 0; i < len(keyFields)-1; i++ {
+		key := "row." + goFieldName(keyFields[i])
+		next := fmt.Sprintf("level%d", i+1)
+		code.WriteString(fmt.Sprintf("%s, ok := %s[%s]\nif !ok {\n", next, level, key))
+		code.WriteString(fmt.Sprintf("%s = make(%s)\n%s[%s] = %s\n}\n", next, levelTypes[i+1], level, key, next))
+		level = next
+	}
+	key := "row." + goFieldName(keyFields[len(keyFields)-1])
+	code.WriteString(fmt.Sprintf("if _, ok := %s[%s]; !ok {\n%s[%s] = row\n}\n}\n", level, key, level, key))
+}
+
+// writeRuntime writes the helpers shared by the loaders of all tables
+func (ge *GoExporter) writeRuntime(packageName string) error {
+	file, err := CreateNamedOutputFile(GoRuntimeFileName, ge.OutputDir, "Go")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	source := goJSONRuntime
+	if ge.Loader == GoLoaderBinary {
+		source = goBinaryRuntime
+	}
+	source = strings.Replace(source, "package protoxls\n", "package "+packageName+"\n", 1)
+	if _, err := file.WriteString(source); err != nil {
+		return fmt.Errorf("failed to write Go runtime: %v", err)
+	}
+	return nil
+}
+
+// fieldType returns the struct field type of a field, including repeated and map fields
+func (ge *GoExporter) fieldType(field *desc.FieldDescriptor) string {
+	switch {
+	case field.IsMap():
+		return fmt.Sprintf("map[%s]%s", ge.elementType(field.GetMapKeyType()), ge.elementType(field.GetMapValueType()))
+	case field.IsRepeated():
+		return "[]" + ge.elementType(field)
+	default:
+		return ge.elementType(field)
+	}
+}
+
+// elementType maps the type of a single field value to its Go type, messages are pointers
+func (ge *GoExporter) elementType(field *desc.FieldDescriptor) string {
+	switch field.GetType().String() {
+	case "TYPE_INT32", "TYPE_SINT32", "TYPE_SFIXED32":
+		return "int32"
+	case "TYPE_INT64", "TYPE_SINT64", "TYPE_SFIXED64":
+		return "int64"
+	case "TYPE_UINT32", "TYPE_FIXED32":
+		return "uint32"
+	case "TYPE_UINT64", "TYPE_FIXED64":
+		return "uint64"
+	case "TYPE_FLOAT":
+		return "float32"
+	case "TYPE_DOUBLE":
+		return "float64"
+	case "TYPE_BOOL":
+		return "bool"
+	case "TYPE_STRING":
+		return "string"
+	case "TYPE_BYTES":
+		return "[]byte"
+	case "TYPE_ENUM":
+		// Enum fields exported as (alias) hold display names, which are no values of the enum
+		if ge.Loader != GoLoaderBinary && ge.JsonStyle != JsonStyleProtoJSON &&
+			getEnumFormat(field, ge.EnumFormat) == EnumFormatAlias {
+			return "string"
+		}
+		return goTypeName(field.GetEnumType())
+	}
+	return "*" + goTypeName(field.GetMessageType())
+}
+
+// readValue returns the expression decoding a scalar or enum value of a field
+func (ge *GoExporter) readValue(field *desc.FieldDescriptor) string {
+	switch field.GetType().String() {
+	case "TYPE_INT32":
+		return "int32(d.varint())"
+	case "TYPE_INT64":
+		return "int64(d.varint())"
+	case "TYPE_UINT32":
+		return "uint32(d.varint())"
+	case "TYPE_UINT64":
+		return "d.varint()"
+	case "TYPE_SINT32":
+		return "d.sint32()"
+	case "TYPE_SINT64":
+		return "d.sint64()"
+	case "TYPE_FIXED32":
+		return "d.fixed32()"
+	case "TYPE_SFIXED32":
+		return "int32(d.fixed32())"
+	case "TYPE_FIXED64":
+		return "d.fixed64()"
+	case "TYPE_SFIXED64":
+		return "int64(d.fixed64())"
+	case "TYPE_FLOAT":
+		return "d.float32()"
+	case "TYPE_DOUBLE":
+		return "d.float64()"
+	case "TYPE_BOOL":
+		return "d.varint() != 0"
+	case "TYPE_STRING":
+		return "d.string()"
+	case "TYPE_BYTES":
+		return "d.bytes()"
+	case "TYPE_ENUM":
+		return goTypeName(field.GetEnumType()) + "(d.varint())"
+	}
+	// Messages are merged by the caller
+	return ""
+}
+
+// jsonFieldName returns the key of a field in the JSON output
+func (ge *GoExporter) jsonFieldName(field *desc.FieldDescriptor) string {
+	if ge.JsonStyle == JsonStyleProtoJSON {
+		return field.GetJSONName()
+	}
+	return field.GetName()
+}
+
+// goTypeName returns the Go name of a message or enum as protoc-gen-go names it, nested types joined
+// to their parents by underscores
+func goTypeName(typeDesc desc.Descriptor) string {
+	name := typeDesc.GetFullyQualifiedName()
+	if pkg := typeDesc.GetFile().GetPackage(); pkg != "" {
+		name = strings.TrimPrefix(name, pkg+".")
+	}
+	return goCamelCase(name)
+}
+
+// goEnumValueName returns the constant of an enum value as protoc-gen-go names it: the value prefixed
+// with its enum type, or with the parent message for nested enums
+func goEnumValueName(enumVal *desc.EnumValueDescriptor) string {
+	prefix := goTypeName(enumVal.GetEnum())
+	if parent, ok := enumVal.GetEnum().GetParent().(*desc.MessageDescriptor); ok {
+		prefix = goTypeName(parent)
+	}
+	return prefix + "_" + enumVal.GetName()
+}
+
+// goFieldName returns the struct field of a proto field as protoc-gen-go names it
+func goFieldName(field *desc.FieldDescriptor) string {
+	return goCamelCase(field.GetName())
+}
+
+// goParameterName returns the lookup parameter of a (keys) field
+func goParameterName(field *desc.FieldDescriptor) string {
+	name := goCamelCase(field.GetName())
+	name = strings.ToLower(name[:1]) + name[1:]
+	// t is the receiver of the lookup methods
+	if token.IsKeyword(name) || name == "t" {
+		name += "_"
+	}
+	return name
+}
+
+// goCamelCase converts a proto name to an exported Go name like protoc-gen-go: underscores followed by a
+// lowercase letter and dots followed by a lowercase letter are dropped, other dots become underscores,
+// and each word starts with an uppercase letter
+func goCamelCase(s string) string {
+	var result []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '.' && i+1 < len(s) && isASCIILower(s[i+1]):
+		case c == '.':
+			result = append(result, '_')
+		case c == '_' && (i == 0 || s[i-1] == '.'):
+			// A leading underscore would make the name unexported
+			result = append(result, 'X')
+		case c == '_' && i+1 < len(s) && isASCIILower(s[i+1]):
+		case c >= '0' && c <= '9':
+			result = append(result, c)
+		default:
+			if isASCIILower(c) {
+				c -= 'a' - 'A'
+			}
+			result = append(result, c)
+			for ; i+1 < len(s) && isASCIILower(s[i+1]); i++ {
+				result = append(result, s[i+1])
+			}
+		}
+	}
+	return string(result)
+}
+
+// isASCIILower reports whether a byte is a lowercase ASCII letter
+func isASCIILower(c byte) bool {
+	return c >= 'a' && c <= 'z'
+}
+
+// writeGoComment writes a comment line per line of text, nothing for an empty text
+func writeGoComment(code *strings.Builder, text string) {
+	if text == "" {
+		return
+	}
+	for _, line := range strings.Split(text, "\n") {
+		code.WriteString(strings.TrimRight("// "+strings.TrimSpace(line), " ") + "\n")
+	}
+}
@@ -0,0 +1,500 @@
+package protoxls
+
+// goJSONRuntime holds the helpers of loaders reading the JSON output with encoding/json
+const goJSONRuntime = `// Code generated by protoxls. DO NOT EDIT.
+
+package protoxls
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// protoxlsEnumString returns the name of an enum value, or its number if it has none
+func protoxlsEnumString(names map[int32]string, value int32) string {
+	if name, ok := names[value]; ok {
+		return name
+	}
+	return strconv.Itoa(int(value))
+}
+
+// protoxlsUnmarshalEnum reads an enum value from its number or name, null reads as zero like in protojson
+func protoxlsUnmarshalEnum(data []byte, values map[string]int32, enumName string) (int32, error) {
+	if string(data) == "null" {
+		return 0, nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var name string
+		if err := json.Unmarshal(data, &name); err != nil {
+			return 0, err
+		}
+		value, ok := values[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown value %q of enum %s", name, enumName)
+		}
+		return value, nil
+	}
+	var value int32
+	if err := json.Unmarshal(data, &value); err != nil {
+		return 0, fmt.Errorf("invalid value %s of enum %s", data, enumName)
+	}
+	return value, nil
+}
+
+// protoxlsFloat reads a float from a number, a numeric string, or "NaN", "Infinity" and "-Infinity"
+type protoxlsFloat float64
+
+func (f *protoxlsFloat) UnmarshalJSON(data []byte) error {
+	text, err := protoxlsUnquote(data)
+	if err != nil || text == "null" {
+		return err
+	}
+	switch text {
+	case "NaN":
+		*f = protoxlsFloat(math.NaN())
+	case "Infinity":
+		*f = protoxlsFloat(math.Inf(1))
+	case "-Infinity":
+		*f = protoxlsFloat(math.Inf(-1))
+	default:
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return fmt.Errorf("invalid float %s", data)
+		}
+		*f = protoxlsFloat(value)
+	}
+	return nil
+}
+
+// protoxlsInt reads a 64-bit integer from a number or a numeric string
+type protoxlsInt int64
+
+func (i *protoxlsInt) UnmarshalJSON(data []byte) error {
+	text, err := protoxlsUnquote(data)
+	if err != nil || text == "null" {
+		return err
+	}
+	value, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid integer %s", data)
+	}
+	*i = protoxlsInt(value)
+	return nil
+}
+
+// protoxlsUint reads an unsigned 64-bit integer from a number or a numeric string
+type protoxlsUint uint64
+
+func (u *protoxlsUint) UnmarshalJSON(data []byte) error {
+	text, err := protoxlsUnquote(data)
+	if err != nil || text == "null" {
+		return err
+	}
+	value, err := strconv.ParseUint(text, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid integer %s", data)
+	}
+	*u = protoxlsUint(value)
+	return nil
+}
+
+// protoxlsUnquote returns the contents of a JSON string, or any other JSON value as is
+func protoxlsUnquote(data []byte) (string, error) {
+	if len(data) == 0 || data[0] != '"' {
+		return string(data), nil
+	}
+	var text string
+	err := json.Unmarshal(data, &text)
+	return text, err
+}
+`
+
+// goBinaryRuntime holds the protobuf wire format decoder of loaders reading the binary output
+const goBinaryRuntime = `// Code generated by protoxls. DO NOT EDIT.
+
+package protoxls
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"strconv"
+)
+
+// Wire types of the protobuf encoding
+const (
+	protoxlsWireVarint     = 0
+	protoxlsWireFixed64    = 1
+	protoxlsWireBytes      = 2
+	protoxlsWireStartGroup = 3
+	protoxlsWireEndGroup   = 4
+	protoxlsWireFixed32    = 5
+
+	// protoxlsWireAny is the wire type of packed values, which are read without tags
+	protoxlsWireAny = -1
+)
+
+// protoxlsEnumString returns the name of an enum value, or its number if it has none
+func protoxlsEnumString(names map[int32]string, value int32) string {
+	if name, ok := names[value]; ok {
+		return name
+	}
+	return strconv.Itoa(int(value))
+}
+
+// protoxlsDecoder reads the fields of a message in the protobuf wire format. Reads after an error
+// return zero values, and the error is reported once the message has been read
+type protoxlsDecoder struct {
+	data []byte
+	num  int32 // Number of the current field
+	typ  int   // Wire type of the current field
+	err  error
+}
+
+// protoxlsUnmarshal decodes a message with the generated unmarshal method of its type
+func protoxlsUnmarshal(data []byte, unmarshal func(d *protoxlsDecoder)) error {
+	d := &protoxlsDecoder{data: data}
+	unmarshal(d)
+	return d.err
+}
+
+// next reads the tag of the next field, or returns false at the end of the message or after an error
+func (d *protoxlsDecoder) next() bool {
+	if d.err != nil || len(d.data) == 0 {
+		return false
+	}
+	tag := d.readVarint()
+	if d.err != nil {
+		return false
+	}
+	if tag>>3 == 0 || tag>>3 > math.MaxInt32 {
+		d.fail("invalid field number")
+		return false
+	}
+	d.num = int32(tag >> 3)
+	d.typ = int(tag & 7)
+	return true
+}
+
+func (d *protoxlsDecoder) varint() uint64 {
+	if !d.expect(protoxlsWireVarint) {
+		return 0
+	}
+	return d.readVarint()
+}
+
+func (d *protoxlsDecoder) sint32() int32 {
+	value := uint32(d.varint())
+	return int32(value>>1) ^ -int32(value&1)
+}
+
+func (d *protoxlsDecoder) sint64() int64 {
+	value := d.varint()
+	return int64(value>>1) ^ -int64(value&1)
+}
+
+func (d *protoxlsDecoder) fixed32() uint32 {
+	if !d.expect(protoxlsWireFixed32) {
+		return 0
+	}
+	if data := d.take(4); data != nil {
+		return binary.LittleEndian.Uint32(data)
+	}
+	return 0
+}
+
+func (d *protoxlsDecoder) fixed64() uint64 {
+	if !d.expect(protoxlsWireFixed64) {
+		return 0
+	}
+	if data := d.take(8); data != nil {
+		return binary.LittleEndian.Uint64(data)
+	}
+	return 0
+}
+
+func (d *protoxlsDecoder) float32() float32 {
+	return math.Float32frombits(d.fixed32())
+}
+
+func (d *protoxlsDecoder) float64() float64 {
+	return math.Float64frombits(d.fixed64())
+}
+
+func (d *protoxlsDecoder) string() string {
+	if !d.expect(protoxlsWireBytes) {
+		return ""
+	}
+	return string(d.take(d.length()))
+}
+
+// bytes returns a copy of a length-delimited value, the loaded data is not kept alive by the rows
+func (d *protoxlsDecoder) bytes() []byte {
+	if !d.expect(protoxlsWireBytes) {
+		return nil
+	}
+	data := d.take(d.length())
+	return append([]byte{}, data...)
+}
+
+// message decodes an embedded message with the unmarshal method of its type
+func (d *protoxlsDecoder) message(unmarshal func(d *protoxlsDecoder)) {
+	if !d.expect(protoxlsWireBytes) {
+		return
+	}
+	data := d.take(d.length())
+	if d.err != nil {
+		return
+	}
+	inner := &protoxlsDecoder{data: data}
+	unmarshal(inner)
+	if inner.err != nil {
+		d.err = inner.err
+	}
+}
+
+// packed calls read for each value of a packed repeated field
+func (d *protoxlsDecoder) packed(read func(d *protoxlsDecoder)) {
+	data := d.take(d.length())
+	if d.err != nil {
+		return
+	}
+	inner := &protoxlsDecoder{data: data, num: d.num, typ: protoxlsWireAny}
+	for len(inner.data) > 0 && inner.err == nil {
+		read(inner)
+	}
+	if inner.err != nil {
+		d.err = inner.err
+	}
+}
+
+// skip skips the value of a field unknown to the generated code
+func (d *protoxlsDecoder) skip() {
+	switch d.typ {
+	case protoxlsWireVarint:
+		d.readVarint()
+	case protoxlsWireFixed64:
+		d.take(8)
+	case protoxlsWireBytes:
+		d.take(d.length())
+	case protoxlsWireStartGroup:
+		num := d.num
+		for {
+			if !d.next() {
+				if d.err == nil {
+					d.fail("unterminated group")
+				}
+				return
+			}
+			if d.typ == protoxlsWireEndGroup {
+				if d.num != num {
+					d.fail("mismatched end group")
+				}
+				return
+			}
+			d.skip()
+		}
+	case protoxlsWireFixed32:
+		d.take(4)
+	default:
+		d.fail("invalid wire type " + strconv.Itoa(d.typ))
+	}
+}
+
+// expect checks that the current field has the wire type of the value read from it
+func (d *protoxlsDecoder) expect(typ int) bool {
+	if d.err != nil {
+		return false
+	}
+	if d.typ != typ && d.typ != protoxlsWireAny {
+		d.fail("wrong wire type " + strconv.Itoa(d.typ) + " of field " + strconv.Itoa(int(d.num)))
+		return false
+	}
+	return true
+}
+
+func (d *protoxlsDecoder) readVarint() uint64 {
+	var value uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		if d.err != nil || len(d.data) == 0 {
+			d.fail("truncated message")
+			return 0
+		}
+		b := d.data[0]
+		d.data = d.data[1:]
+		value |= uint64(b&0x7F) << shift
+		if b&0x80 == 0 {
+			return value
+		}
+	}
+	d.fail("malformed varint")
+	return 0
+}
+
+// length reads the length of a length-delimited value
+func (d *protoxlsDecoder) length() int {
+	length := d.readVarint()
+	if length > uint64(len(d.data)) {
+		d.fail("truncated message")
+		return 0
+	}
+	return int(length)
+}
+
+// take consumes the next n bytes of the message, or returns nil if there are fewer
+func (d *protoxlsDecoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n > len(d.data) {
+		d.fail("truncated message")
+		return nil
+	}
+	data := d.data[:n:n]
+	d.data = d.data[n:]
+	return data
+}
+
+func (d *protoxlsDecoder) fail(message string) {
+	if d.err == nil {
+		d.err = errors.New(message)
+	}
+}
+
+// protoxlsRows splits the binary output of a table into rows, in the raw, container, wrapper or indexed layout
+func protoxlsRows(data []byte) ([][]byte, error) {
+	switch {
+	case len(data) >= 4 && string(data[:4]) == "PXLS":
+		return protoxlsContainerRows(data)
+	case len(data) >= 4 && string(data[:4]) == "PXLI":
+		return protoxlsIndexedRows(data)
+	case len(data) > 0 && data[0] == 0x0A:
+		// Wrapper messages start with the tag of field 1, raw rows with a big-endian length far below 0x0A000000
+		var rows [][]byte
+		d := &protoxlsDecoder{data: data}
+		for d.next() {
+			if d.num == 1 && d.typ == protoxlsWireBytes {
+				rows = append(rows, d.take(d.length()))
+			} else {
+				d.skip()
+			}
+		}
+		return rows, d.err
+	default:
+		r := &protoxlsHeader{data: data}
+		return r.rows(-1)
+	}
+}
+
+func protoxlsContainerRows(data []byte) ([][]byte, error) {
+	if len(data) < 8 || crc32.ChecksumIEEE(data[:len(data)-4]) != binary.BigEndian.Uint32(data[len(data)-4:]) {
+		return nil, errors.New("container checksum mismatch")
+	}
+	r := &protoxlsHeader{data: data[:len(data)-4], pos: 4}
+	if version := r.uint16(); version != 1 && r.err == nil {
+		return nil, fmt.Errorf("unsupported container version %d", version)
+	}
+	r.uint16()
+	r.skip(int(r.uint16()))
+	r.skip(int(r.uint16()))
+	for keyCount := r.uint16(); keyCount > 0 && r.err == nil; keyCount-- {
+		r.skip(int(r.uint16()))
+	}
+	r.skip(32)
+	rowCount := r.uint32()
+	r.skip(int(r.uint32()))
+	return r.rows(int64(rowCount))
+}
+
+func protoxlsIndexedRows(data []byte) ([][]byte, error) {
+	r := &protoxlsHeader{data: data, pos: 4}
+	if version := r.uint16(); version != 1 && r.err == nil {
+		return nil, fmt.Errorf("unsupported indexed version %d", version)
+	}
+	keyLevels := uint64(r.uint16())
+	rowCount := uint64(r.uint32())
+	r.pos = 16
+	indexOffset := r.uint64()
+	if r.err != nil {
+		return nil, r.err
+	}
+	entrySize := keyLevels*9 + 12
+	if indexOffset > uint64(len(data)) || rowCount*entrySize > uint64(len(data))-indexOffset {
+		return nil, errors.New("truncated index")
+	}
+
+	// Rows are stored in index order, which is sheet order for rows with equal keys
+	rows := make([][]byte, 0, rowCount)
+	for i := uint64(0); i < rowCount; i++ {
+		entryEnd := indexOffset + (i+1)*entrySize
+		rowOffset := binary.BigEndian.Uint64(data[entryEnd-12:])
+		rowLength := uint64(binary.BigEndian.Uint32(data[entryEnd-4:]))
+		if rowOffset > uint64(len(data)) || rowLength > uint64(len(data))-rowOffset {
+			return nil, errors.New("row out of bounds")
+		}
+		rows = append(rows, data[rowOffset:rowOffset+rowLength])
+	}
+	return rows, nil
+}
+
+// protoxlsHeader reads the big-endian headers and length-prefixed rows of the raw and container layouts
+type protoxlsHeader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *protoxlsHeader) take(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.data)-r.pos {
+		r.err = errors.New("truncated data")
+		return nil
+	}
+	data := r.data[r.pos : r.pos+n : r.pos+n]
+	r.pos += n
+	return data
+}
+
+func (r *protoxlsHeader) skip(n int) {
+	r.take(n)
+}
+
+func (r *protoxlsHeader) uint16() uint16 {
+	if data := r.take(2); data != nil {
+		return binary.BigEndian.Uint16(data)
+	}
+	return 0
+}
+
+func (r *protoxlsHeader) uint32() uint32 {
+	if data := r.take(4); data != nil {
+		return binary.BigEndian.Uint32(data)
+	}
+	return 0
+}
+
+func (r *protoxlsHeader) uint64() uint64 {
+	if data := r.take(8); data != nil {
+		return binary.BigEndian.Uint64(data)
+	}
+	return 0
+}
+
+// rows reads count rows prefixed by their length, or rows up to the end of the data if count is negative
+func (r *protoxlsHeader) rows(count int64) ([][]byte, error) {
+	var rows [][]byte
+	for i := int64(0); r.err == nil && (count < 0 && r.pos < len(r.data) || i < count); i++ {
+		row := r.take(int(r.uint32()))
+		if r.err == nil {
+			rows = append(rows, row)
+		}
+	}
+	return rows, r.err
+}
+`
@@ -3,17 +3,26 @@ package protoxls
 import (
 	"encoding/json"
 	"fmt"
-	"sort"
 	"strings"
 
+	"github.com/golang/protobuf/jsonpb"
 	"github.com/jhump/protoreflect/desc"
 	"github.com/jhump/protoreflect/dynamic"
 )
 
+const (
+	// JsonStyleDefault writes proto field names, enum numbers and 64-bit integers as numbers
+	JsonStyleDefault = "default"
+	// JsonStyleProtoJSON follows the canonical proto3 JSON mapping
+	JsonStyleProtoJSON = "protojson"
+)
+
 // JsonExporter exports configuration data to JSON format
 type JsonExporter struct {
 	OutputDir     string // Custom output directory, defaults to DefaultOutputDir if empty
 	CompactFormat bool   // Whether to compress each data entry to a single line
+	Style         string // JSON mapping style, JsonStyleDefault if empty
+	EnumFormat    string // How enum fields are exported in the default style, EnumFormatNumber if empty
 }
 
 // ExportResult exports configuration data to JSON format
@@ -56,7 +65,7 @@ func (je *JsonExporter) ExportResult(store *TableStore) error {
 				formattedValue := string(valueBytes)
 
 				// Write key-value pair with proper formatting (space around colon)
-				keyStr := fmt.Sprintf(`    "%s": %s`, key.String(), formattedValue)
+				keyStr := fmt.Sprintf("    %s: %s", quoteJSONString(key.String()), formattedValue)
 				if i < len(keys)-1 {
 					keyStr += ","
 				}
@@ -83,7 +92,10 @@ func (je *JsonExporter) ExportResult(store *TableStore) error {
 
 		for i, message := range messages {
 			// Convert dynamic message to JSON by converting to ordered map
-			messageData := je.convertMessageToMap(message)
+			messageData, err := je.convertMessage(message)
+			if err != nil {
+				return err
+			}
 
 			// Marshal the message as JSON
 			var jsonBytes []byte
@@ -135,7 +147,7 @@ func (je *JsonExporter) exportStoreToInterface(store *TableStore) (interface{},
 	} else {
 		message := store.GetFirstMessage()
 		if message != nil {
-			return je.convertMessageToMap(message), nil
+			return je.convertMessage(message)
 		}
 		return nil, nil
 	}
@@ -147,6 +159,22 @@ type OrderedMap struct {
 	Values map[string]interface{}
 }
 
+// NewOrderedMap creates an empty ordered map
+func NewOrderedMap() *OrderedMap {
+	return &OrderedMap{
+		Keys:   make([]string, 0),
+		Values: make(map[string]interface{}),
+	}
+}
+
+// Set sets the value of a key, appending the key if it is new
+func (om *OrderedMap) Set(key string, value interface{}) {
+	if _, exists := om.Values[key]; !exists {
+		om.Keys = append(om.Keys, key)
+	}
+	om.Values[key] = value
+}
+
 // MarshalJSON implements json.Marshaler to maintain order during JSON serialization
 func (om *OrderedMap) MarshalJSON() ([]byte, error) {
 	var parts []string
@@ -156,11 +184,27 @@ func (om *OrderedMap) MarshalJSON() ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
-		parts = append(parts, fmt.Sprintf(`"%s": %s`, key, string(valueBytes)))
+		parts = append(parts, fmt.Sprintf("%s: %s", quoteJSONString(key), string(valueBytes)))
 	}
 	return []byte("{" + strings.Join(parts, ", ") + "}"), nil
 }
 
+// convertMessage converts a dynamic message to a JSON serializable value in the configured style
+func (je *JsonExporter) convertMessage(msg *dynamic.Message) (interface{}, error) {
+	if je.Style != JsonStyleProtoJSON {
+		return je.convertMessageToMap(msg), nil
+	}
+
+	// Canonical proto3 JSON: lowerCamelCase or json_name keys, enum names, 64-bit integers as strings
+	// and well-known types, the surrounding output is re-indented by encoding/json
+	marshaler := &jsonpb.Marshaler{EmitDefaults: true}
+	messageBytes, err := msg.MarshalJSONPB(marshaler)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal message to protojson: %v", err)
+	}
+	return json.RawMessage(messageBytes), nil
+}
+
 // convertMessageToMap converts a dynamic message to ordered map for JSON serialization
 func (je *JsonExporter) convertMessageToMap(msg *dynamic.Message) *OrderedMap {
 	result := &OrderedMap{
@@ -170,10 +214,7 @@ func (je *JsonExporter) convertMessageToMap(msg *dynamic.Message) *OrderedMap {
 	descriptor := msg.GetMessageDescriptor()
 
 	// Get fields and sort them by field number to maintain proto definition order
-	fields := descriptor.GetFields()
-	sort.Slice(fields, func(i, j int) bool {
-		return fields[i].GetNumber() < fields[j].GetNumber()
-	})
+	fields := getSortedFields(descriptor)
 
 	for _, field := range fields {
 		value := msg.GetField(field)
@@ -202,8 +243,11 @@ func (je *JsonExporter) convertSingleFieldValue(value interface{}, field *desc.F
 			return je.convertMessageToMap(dmsg)
 		}
 	case "TYPE_ENUM":
-		// Return enum as number
-		return value
+		if number, ok := value.(int32); ok {
+			return convertEnumValue(number, field, je.EnumFormat)
+		}
+	case "TYPE_FLOAT", "TYPE_DOUBLE":
+		return convertJSONFloat(value)
 	default:
 		return value
 	}
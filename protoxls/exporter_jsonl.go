@@ -0,0 +1,117 @@
+package protoxls
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// JsonLinesExporter exports configuration data as JSON Lines (NDJSON), one row per line
+type JsonLinesExporter struct {
+	OutputDir  string // Custom output directory, defaults to DefaultOutputDir if empty
+	Style      string // JSON mapping style of each row, JsonStyleDefault if empty
+	KeyField   string // Name of the field holding the (keys) path of each row, omitted if empty
+	EnumFormat string // How enum fields are exported in the default style, EnumFormatNumber if empty
+}
+
+// ExportResult exports every row of the table in sheet order, ignoring the (keys) hierarchy
+func (jle *JsonLinesExporter) ExportResult(store *TableStore) error {
+	var keyNames []string
+	if jle.KeyField != "" {
+		keyFields, err := getTableKeyFields(store)
+		if err != nil {
+			return err
+		}
+		for _, keyField := range keyFields {
+			keyNames = append(keyNames, keyField.GetName())
+		}
+	}
+
+	// A duplicate key would be read differently by different decoders, protojson accepts both field names
+	if len(keyNames) > 0 {
+		msgDesc := store.GetMessageDescriptor()
+		for _, field := range msgDesc.GetFields() {
+			if jle.KeyField == field.GetName() || jle.KeyField == field.GetJSONName() {
+				return fmt.Errorf("JSON Lines key field %s collides with field %s of message %s, use another -jsonl_key", jle.KeyField, field.GetName(), msgDesc.GetName())
+			}
+		}
+	}
+
+	file, err := CreateOutputFile(store, jle.OutputDir, "JSONL")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// Rows are converted and written one at a time, the output is never held in memory as a whole
+	rowConverter := &JsonExporter{Style: jle.Style, EnumFormat: jle.EnumFormat}
+	writer := bufio.NewWriter(file)
+	for i, message := range store.GetAllMessages() {
+		messageData, err := rowConverter.convertMessage(message)
+		if err != nil {
+			return err
+		}
+		lineBytes, err := json.Marshal(messageData)
+		if err != nil {
+			return fmt.Errorf("failed to marshal row %d: %v", i+1, err)
+		}
+
+		if len(keyNames) > 0 {
+			keyPath := make([]interface{}, 0, len(keyNames))
+			for _, keyName := range keyNames {
+				key, err := store.extractKeyFromMessage(message, keyName)
+				if err != nil {
+					return fmt.Errorf("failed to extract key of row %d: %v", i+1, err)
+				}
+				if key.KeyType == KeyTypeInteger {
+					keyPath = append(keyPath, key.IntegerValue)
+				} else {
+					keyPath = append(keyPath, key.StringValue)
+				}
+			}
+			if lineBytes, err = prependJSONField(lineBytes, jle.KeyField, keyPath); err != nil {
+				return err
+			}
+		}
+
+		if _, err := writer.Write(append(lineBytes, '\n')); err != nil {
+			return fmt.Errorf("failed to write JSONL: %v", err)
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write JSONL: %v", err)
+	}
+	return nil
+}
+
+// prependJSONField inserts a field at the front of a marshaled JSON object
+func prependJSONField(objectBytes []byte, name string, value interface{}) ([]byte, error) {
+	nameBytes, err := json.Marshal(name)
+	if err != nil {
+		return nil, err
+	}
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s: %v", name, err)
+	}
+
+	// Compact first so that the remainder of the object starts right after the opening brace
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, objectBytes); err != nil {
+		return nil, err
+	}
+	rest := compacted.Bytes()[1:]
+
+	var result bytes.Buffer
+	result.WriteByte('{')
+	result.Write(nameBytes)
+	result.WriteByte(':')
+	result.Write(valueBytes)
+	if len(rest) > 1 {
+		result.WriteByte(',')
+	}
+	result.Write(rest)
+	return result.Bytes(), nil
+}
@@ -0,0 +1,371 @@
+package protoxls
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// JsonSchemaDialect is the JSON Schema draft the generated schemas declare
+	JsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+	// jsonSchemaDefsRef is the reference prefix of definitions in $defs
+	jsonSchemaDefsRef = "#/$defs/"
+	// integerStringPattern matches integer keys and quoted 64-bit integers
+	integerStringPattern = "^-?[0-9]+$"
+	// unsignedStringPattern matches quoted unsigned 64-bit integers
+	unsignedStringPattern = "^[0-9]+$"
+)
+
+// JsonSchemaExporter exports a JSON Schema describing the output of JsonExporter
+type JsonSchemaExporter struct {
+	OutputDir  string // Custom output directory, defaults to DefaultOutputDir if empty
+	Style      string // JSON mapping style of the described output, JsonStyleDefault if empty
+	EnumFormat string // How enum fields are exported in the default style, EnumFormatNumber if empty
+}
+
+// ExportResult exports the JSON Schema of the table
+func (jse *JsonSchemaExporter) ExportResult(store *TableStore) error {
+	schema, err := jse.buildTableSchema(store)
+	if err != nil {
+		return err
+	}
+
+	schemaBytes, err := json.MarshalIndent(schema, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON Schema: %v", err)
+	}
+
+	file, err := CreateOutputFile(store, jse.OutputDir, "JSON Schema")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(schemaBytes, '\n')); err != nil {
+		return fmt.Errorf("failed to write JSON Schema: %v", err)
+	}
+	return nil
+}
+
+// buildTableSchema builds the root schema, nesting one keyed object level per (keys) field
+func (jse *JsonSchemaExporter) buildTableSchema(store *TableStore) (*OrderedMap, error) {
+	msgDesc := store.GetMessageDescriptor()
+	tableName := GetTableName(store)
+	defs := NewOrderedMap()
+
+	rowSchema := NewOrderedMap()
+	rowSchema.Set("$ref", jse.addMessageDef(msgDesc, defs))
+
+	keyFields, err := getTableKeyFields(store)
+	if err != nil {
+		return nil, err
+	}
+
+	var bodySchema *OrderedMap
+	if len(keyFields) == 0 {
+		bodySchema = NewOrderedMap()
+		bodySchema.Set("type", "array")
+		bodySchema.Set("items", rowSchema)
+	} else {
+		// JsonExporter keeps the first row of each key path, so the innermost level holds a single row
+		bodySchema = rowSchema
+		for i := len(keyFields) - 1; i >= 0; i-- {
+			bodySchema = jse.buildKeyLevelSchema(keyFields[i], bodySchema)
+		}
+	}
+
+	schema := NewOrderedMap()
+	schema.Set("$schema", JsonSchemaDialect)
+	schema.Set("$id", tableName+".schema.json")
+	schema.Set("title", tableName)
+	if comment := getDescriptorComment(msgDesc); comment != "" {
+		schema.Set("description", comment)
+	}
+	for _, key := range bodySchema.Keys {
+		if _, exists := schema.Values[key]; exists {
+			continue // Keep the table comment over the key level description
+		}
+		schema.Set(key, bodySchema.Values[key])
+	}
+	schema.Set("$defs", defs)
+	return schema, nil
+}
+
+// buildKeyLevelSchema wraps the schema of the next level into an object keyed by the given field
+func (jse *JsonSchemaExporter) buildKeyLevelSchema(keyField *desc.FieldDescriptor, valueSchema *OrderedMap) *OrderedMap {
+	schema := NewOrderedMap()
+	schema.Set("type", "object")
+	schema.Set("description", fmt.Sprintf("Rows keyed by %s", keyField.GetName()))
+	if isIntegerKeyField(keyField) {
+		propertyNames := NewOrderedMap()
+		propertyNames.Set("pattern", integerStringPattern)
+		schema.Set("propertyNames", propertyNames)
+	}
+	schema.Set("additionalProperties", valueSchema)
+	return schema
+}
+
+// getTableKeyFields resolves the (keys) fields of the table message
+func getTableKeyFields(store *TableStore) ([]*desc.FieldDescriptor, error) {
+	msgDesc := store.GetMessageDescriptor()
+	keyNames := store.GetKeyFieldNames()
+	if len(keyNames) == 0 {
+		if options := msgDesc.GetMessageOptions(); options != nil {
+			if keys, ok := proto.GetExtension(options, E_Keys).(string); ok && keys != "" {
+				keyNames = strings.Split(keys, ";")
+			}
+		}
+	}
+
+	keyFields := make([]*desc.FieldDescriptor, 0, len(keyNames))
+	for _, keyName := range keyNames {
+		field := msgDesc.FindFieldByName(strings.TrimSpace(keyName))
+		if field == nil {
+			return nil, fmt.Errorf("key field %s not found in message %s", keyName, msgDesc.GetName())
+		}
+		keyFields = append(keyFields, field)
+	}
+	return keyFields, nil
+}
+
+// isIntegerKeyField checks if the keys of a field are always written as integers
+func isIntegerKeyField(field *desc.FieldDescriptor) bool {
+	switch field.GetType().String() {
+	case "TYPE_INT32", "TYPE_SINT32", "TYPE_SFIXED32", "TYPE_UINT32", "TYPE_FIXED32",
+		"TYPE_INT64", "TYPE_SINT64", "TYPE_SFIXED64", "TYPE_UINT64", "TYPE_FIXED64":
+		return true
+	default:
+		// String keys that look like numbers are also written as integers, but any string is valid
+		return false
+	}
+}
+
+// addMessageDef adds the definition of a message and the types it uses to $defs, returning its reference
+func (jse *JsonSchemaExporter) addMessageDef(msgDesc *desc.MessageDescriptor, defs *OrderedMap) string {
+	name := msgDesc.GetFullyQualifiedName()
+	if _, exists := defs.Values[name]; exists {
+		return jsonSchemaDefsRef + name
+	}
+
+	schema := NewOrderedMap()
+	// Register before walking the fields so recursive messages terminate
+	defs.Set(name, schema)
+
+	schema.Set("type", "object")
+	if comment := getDescriptorComment(msgDesc); comment != "" {
+		schema.Set("description", comment)
+	}
+
+	// Get fields and sort them by field number to match the JsonExporter output order
+	fields := getSortedFields(msgDesc)
+
+	properties := NewOrderedMap()
+	required := make([]string, 0, len(fields))
+	for _, field := range fields {
+		fieldName := field.GetName()
+		if jse.Style == JsonStyleProtoJSON {
+			fieldName = field.GetJSONName()
+		}
+		properties.Set(fieldName, jse.buildFieldSchema(field, defs))
+
+		// Every field is written, except unset oneof members in protojson output
+		if jse.Style != JsonStyleProtoJSON || field.GetOneOf() == nil {
+			required = append(required, fieldName)
+		}
+	}
+	schema.Set("properties", properties)
+	schema.Set("required", required)
+	schema.Set("additionalProperties", false)
+
+	return jsonSchemaDefsRef + name
+}
+
+// buildFieldSchema builds the schema of a field value, including repeated and map fields
+func (jse *JsonSchemaExporter) buildFieldSchema(field *desc.FieldDescriptor, defs *OrderedMap) *OrderedMap {
+	var schema *OrderedMap
+	switch {
+	case field.IsMap():
+		schema = NewOrderedMap()
+		schema.Set("type", "object")
+		schema.Set("additionalProperties", jse.buildValueSchema(field.GetMapValueType(), defs))
+	case field.IsRepeated():
+		schema = NewOrderedMap()
+		schema.Set("type", "array")
+		schema.Set("items", jse.buildValueSchema(field, defs))
+	case field.GetMessageType() != nil:
+		// Unset message fields are written as null
+		nullSchema := NewOrderedMap()
+		nullSchema.Set("type", "null")
+		schema = NewOrderedMap()
+		schema.Set("anyOf", []interface{}{jse.buildValueSchema(field, defs), nullSchema})
+	default:
+		schema = jse.buildValueSchema(field, defs)
+	}
+
+	if title := getFieldText(field); title != "" {
+		schema.Set("title", title)
+	}
+	if comment := getDescriptorComment(field); comment != "" {
+		schema.Set("description", comment)
+	}
+	return schema
+}
+
+// buildValueSchema builds the schema of a single value of a field
+func (jse *JsonSchemaExporter) buildValueSchema(field *desc.FieldDescriptor, defs *OrderedMap) *OrderedMap {
+	schema := NewOrderedMap()
+	protoJSON := jse.Style == JsonStyleProtoJSON
+
+	switch field.GetType().String() {
+	case "TYPE_MESSAGE", "TYPE_GROUP":
+		if protoJSON {
+			if wellKnown := buildWellKnownTypeSchema(field.GetMessageType()); wellKnown != nil {
+				return wellKnown
+			}
+		}
+		schema.Set("$ref", jse.addMessageDef(field.GetMessageType(), defs))
+	case "TYPE_ENUM":
+		format := EnumFormatName
+		if !protoJSON {
+			format = getEnumFormat(field, jse.EnumFormat)
+		}
+		schema.Set("$ref", jse.addEnumDef(field.GetEnumType(), format, defs))
+	case "TYPE_INT32", "TYPE_SINT32", "TYPE_SFIXED32":
+		schema.Set("type", "integer")
+		schema.Set("minimum", math.MinInt32)
+		schema.Set("maximum", math.MaxInt32)
+	case "TYPE_UINT32", "TYPE_FIXED32":
+		schema.Set("type", "integer")
+		schema.Set("minimum", 0)
+		schema.Set("maximum", uint32(math.MaxUint32))
+	case "TYPE_INT64", "TYPE_SINT64", "TYPE_SFIXED64":
+		if protoJSON {
+			// 64-bit integers are quoted in protojson
+			schema.Set("type", "string")
+			schema.Set("pattern", integerStringPattern)
+		} else {
+			schema.Set("type", "integer")
+		}
+	case "TYPE_UINT64", "TYPE_FIXED64":
+		if protoJSON {
+			schema.Set("type", "string")
+			schema.Set("pattern", unsignedStringPattern)
+		} else {
+			schema.Set("type", "integer")
+			schema.Set("minimum", 0)
+		}
+	case "TYPE_FLOAT", "TYPE_DOUBLE":
+		// Non-finite values are written as strings in both styles
+		numberSchema := NewOrderedMap()
+		numberSchema.Set("type", "number")
+		specialSchema := NewOrderedMap()
+		specialSchema.Set("enum", []string{"NaN", "Infinity", "-Infinity"})
+		schema.Set("anyOf", []interface{}{numberSchema, specialSchema})
+	case "TYPE_BOOL":
+		schema.Set("type", "boolean")
+	case "TYPE_STRING":
+		schema.Set("type", "string")
+	case "TYPE_BYTES":
+		schema.Set("type", "string")
+		schema.Set("contentEncoding", "base64")
+	}
+	return schema
+}
+
+// addEnumDef adds the definition of an enum exported in the given format to $defs, returning its reference.
+// Definitions of the number format use the enum name, other formats append the format after a hyphen,
+// which never appears in proto names
+func (jse *JsonSchemaExporter) addEnumDef(enumDesc *desc.EnumDescriptor, format string, defs *OrderedMap) string {
+	name := enumDesc.GetFullyQualifiedName()
+	if format != EnumFormatNumber {
+		name += "-" + format
+	}
+	if _, exists := defs.Values[name]; exists {
+		return jsonSchemaDefsRef + name
+	}
+
+	schema := NewOrderedMap()
+	if format == EnumFormatNumber {
+		schema.Set("type", "integer")
+	} else {
+		schema.Set("type", "string")
+	}
+	if comment := getDescriptorComment(enumDesc); comment != "" {
+		schema.Set("description", comment)
+	}
+
+	displayNames := getEnumDisplayNames(enumDesc)
+	values := make([]interface{}, 0, len(enumDesc.GetValues()))
+	seenConsts := make(map[interface{}]bool)
+	for i, enumVal := range enumDesc.GetValues() {
+		var constValue interface{}
+		switch format {
+		case EnumFormatName:
+			constValue = enumVal.GetName()
+		case EnumFormatAlias:
+			// Aliased values are exported as the display name of the value found by number
+			constValue = getEnumDisplayName(enumDesc.FindValueByNumber(enumVal.GetNumber()))
+		default:
+			constValue = enumVal.GetNumber()
+		}
+		// Aliased values share a number and would match twice in oneOf
+		if seenConsts[constValue] {
+			continue
+		}
+		seenConsts[constValue] = true
+
+		valueSchema := NewOrderedMap()
+		valueSchema.Set("const", constValue)
+		valueSchema.Set("title", displayNames[i])
+		if comment := getDescriptorComment(enumVal); comment != "" {
+			valueSchema.Set("description", comment)
+		}
+		values = append(values, valueSchema)
+	}
+	schema.Set("oneOf", values)
+
+	defs.Set(name, schema)
+	return jsonSchemaDefsRef + name
+}
+
+// buildWellKnownTypeSchema returns the schema of the special protojson forms of well-known types,
+// or nil if the message is written as a regular object
+func buildWellKnownTypeSchema(msgDesc *desc.MessageDescriptor) *OrderedMap {
+	schema := NewOrderedMap()
+	switch msgDesc.GetFullyQualifiedName() {
+	case "google.protobuf.Timestamp":
+		schema.Set("type", "string")
+		schema.Set("format", "date-time")
+	case "google.protobuf.Duration":
+		schema.Set("type", "string")
+		schema.Set("pattern", `^-?[0-9]+(\.[0-9]+)?s$`)
+	case "google.protobuf.FieldMask":
+		schema.Set("type", "string")
+	case "google.protobuf.Struct":
+		schema.Set("type", "object")
+	case "google.protobuf.ListValue":
+		schema.Set("type", "array")
+	case "google.protobuf.Value":
+		// Any JSON value
+	case "google.protobuf.Any":
+		schema.Set("type", "object")
+		schema.Set("required", []string{"@type"})
+	case "google.protobuf.BoolValue":
+		schema.Set("type", []string{"boolean", "null"})
+	case "google.protobuf.StringValue", "google.protobuf.BytesValue",
+		"google.protobuf.Int64Value", "google.protobuf.UInt64Value":
+		schema.Set("type", []string{"string", "null"})
+	case "google.protobuf.Int32Value", "google.protobuf.UInt32Value":
+		schema.Set("type", []string{"integer", "null"})
+	case "google.protobuf.FloatValue", "google.protobuf.DoubleValue":
+		schema.Set("type", []string{"number", "string", "null"})
+	default:
+		return nil
+	}
+	return schema
+}
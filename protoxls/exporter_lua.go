@@ -2,16 +2,69 @@ package protoxls
 
 import (
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/jhump/protoreflect/desc"
 	"github.com/jhump/protoreflect/dynamic"
 )
 
+// luaReadOnlyHelper wraps tables recursively in proxies whose __newindex raises an error.
+// Shared subtables are wrapped once, __len and __pairs keep # and pairs working on Lua 5.3+.
+// Lua 5.1 and LuaJIT ignore both metamethods, so every proxy also has :__pairs(), :__ipairs()
+// and :__len() methods, which fields of the same name take precedence over
+const luaReadOnlyHelper = `local readonly
+do
+    local proxies, sources = {}, {}
+    local methods = {
+        __pairs = function(self) return next, sources[self], nil end,
+        __ipairs = function(self) return ipairs(sources[self]) end,
+        __len = function(self) return #sources[self] end,
+    }
+    readonly = function(t)
+        if proxies[t] then
+            return proxies[t]
+        end
+        local proxy = setmetatable({}, {
+            __index = function(_, k)
+                local v = t[k]
+                if v == nil then
+                    return methods[k]
+                end
+                return v
+            end,
+            __newindex = function(_, k)
+                error("attempt to modify read-only config field '" .. tostring(k) .. "'", 2)
+            end,
+            __len = function() return #t end,
+            __pairs = function() return next, t, nil end,
+            __metatable = false,
+        })
+        proxies[t], sources[proxy] = proxy, t
+        for k, v in pairs(t) do
+            if type(v) == "table" then
+                t[k] = readonly(v)
+            end
+        end
+        return proxy
+    end
+end
+
+`
+
 // LuaExporter exports configuration data to Lua format
 type LuaExporter struct {
 	OutputDir     string // Custom output directory, defaults to DefaultOutputDir if empty
 	CompactFormat bool   // Whether to compress each data entry to a single line
+	ModuleFormat  bool   // Whether to declare a local table and return it instead of assigning a global
+	ReadOnly      bool   // Whether to wrap the table in recursive read-only proxies
+	Optimize      bool   // Whether to omit default values and share identical subtables
+	EnumFormat    string // How enum fields are exported, EnumFormatNumber if empty
+	// Maximum estimated constants per generated function, MaxLuaChunkConstants if 0.
+	// Larger tables are filled by several loader functions
+	ChunkConstants int
+
+	optimizer *luaOptimizer // Set while an optimized table is being generated
 }
 
 // ExportResult exports configuration data to Lua format
@@ -27,71 +80,118 @@ func (le *LuaExporter) ExportResult(store *TableStore) error {
 	// Get table name using shared function
 	tableName := GetTableName(store)
 
-	if store.HasChildStores() {
-		// Export as table structure with formatted output
-		keys := store.GetAllKeys()
-
-		// Write table name and opening brace
-		if _, err := file.WriteString(fmt.Sprintf("%s = {\n", tableName)); err != nil {
-			return fmt.Errorf("failed to write table declaration: %v", err)
+	if le.ReadOnly {
+		if _, err := file.WriteString(luaReadOnlyHelper); err != nil {
+			return fmt.Errorf("failed to write read-only helper: %v", err)
 		}
+	}
 
-		for i, key := range keys {
-			childStore := store.GetChildStore(key)
-			if childStore != nil {
-				keyStr := le.formatLuaKey(key)
-				childCode := le.generateLuaCode(childStore, 0)
+	declaration := tableName
+	if le.ModuleFormat {
+		declaration = "local " + tableName
+	}
 
-				// Write key-value pair with proper formatting
-				lineCode := fmt.Sprintf("    [%s] = %s", keyStr, childCode)
-				if i < len(keys)-1 {
-					lineCode += ","
-				}
-				lineCode += "\n"
+	if le.Optimize {
+		if err := le.writeOptimizedLuaTable(file, store, tableName, declaration); err != nil {
+			return err
+		}
+	} else if err := le.writeLuaTable(file, store, tableName, declaration); err != nil {
+		return err
+	}
 
-				if _, err := file.WriteString(lineCode); err != nil {
-					return fmt.Errorf("failed to write lua code: %v", err)
-				}
-			}
+	if footer := le.generateLuaFooter(tableName); footer != "" {
+		if _, err := file.WriteString(footer); err != nil {
+			return fmt.Errorf("failed to write lua code: %v", err)
 		}
+	}
+
+	return nil
+}
 
-		// Write closing brace
-		if _, err := file.WriteString("}"); err != nil {
-			return fmt.Errorf("failed to write closing brace: %v", err)
+// writeLuaTable writes the table declaration with one entry per key or row
+func (le *LuaExporter) writeLuaTable(writer io.StringWriter, store *TableStore, tableName, declaration string) error {
+	// Collect the entries first, as tables exceeding the constant limit are split into loader functions
+	keyed := store.HasChildStores()
+	var entryKeys, entryCodes []string
+	if keyed {
+		// Export as table structure with formatted output
+		for _, key := range store.GetAllKeys() {
+			childStore := store.GetChildStore(key)
+			if childStore != nil {
+				entryKeys = append(entryKeys, le.formatLuaKey(key))
+				entryCodes = append(entryCodes, le.generateLuaCode(childStore, 0))
+			}
 		}
 	} else {
 		// Export each message as one line in a table
-		messages := store.GetAllMessages()
+		for i, message := range store.GetAllMessages() {
+			entryKeys = append(entryKeys, fmt.Sprintf("%d", i+1))
+			entryCodes = append(entryCodes, le.generateLuaMessage(message, 0))
+		}
+	}
 
-		// Write table name and opening brace
-		if _, err := file.WriteString(fmt.Sprintf("%s = {\n", tableName)); err != nil {
+	statements := make([]string, len(entryCodes))
+	for i, code := range entryCodes {
+		statements[i] = fmt.Sprintf("%s[%s] = %s", tableName, entryKeys[i], code)
+	}
+	if chunkStarts := splitLuaChunks(statements, le.chunkConstants()); len(chunkStarts) > 1 {
+		if _, err := writer.WriteString(fmt.Sprintf("%s = {}\n", declaration)); err != nil {
 			return fmt.Errorf("failed to write table declaration: %v", err)
 		}
+		return writeLuaLoaders(writer, statements, chunkStarts)
+	}
 
-		for i, message := range messages {
-			messageCode := le.generateLuaMessage(message, 0)
-
-			// Write this message as one line with proper formatting
-			lineCode := fmt.Sprintf("    %s", messageCode)
-			if i < len(messages)-1 {
-				lineCode += ","
-			}
-			lineCode += "\n"
+	// Write table name and opening brace
+	if _, err := writer.WriteString(fmt.Sprintf("%s = {\n", declaration)); err != nil {
+		return fmt.Errorf("failed to write table declaration: %v", err)
+	}
 
-			if _, err := file.WriteString(lineCode); err != nil {
-				return fmt.Errorf("failed to write lua code: %v", err)
-			}
+	for i, code := range entryCodes {
+		// Write key-value pair or message as one line with proper formatting
+		lineCode := fmt.Sprintf("    %s", code)
+		if keyed {
+			lineCode = fmt.Sprintf("    [%s] = %s", entryKeys[i], code)
+		}
+		if i < len(entryCodes)-1 {
+			lineCode += ","
 		}
+		lineCode += "\n"
 
-		// Write closing brace
-		if _, err := file.WriteString("}"); err != nil {
-			return fmt.Errorf("failed to write closing brace: %v", err)
+		if _, err := writer.WriteString(lineCode); err != nil {
+			return fmt.Errorf("failed to write lua code: %v", err)
 		}
 	}
 
+	// Write closing brace
+	if _, err := writer.WriteString("}"); err != nil {
+		return fmt.Errorf("failed to write closing brace: %v", err)
+	}
+
 	return nil
 }
 
+// chunkConstants returns the maximum number of constants per generated function
+func (le *LuaExporter) chunkConstants() int {
+	if le.ChunkConstants > 0 {
+		return le.ChunkConstants
+	}
+	return MaxLuaChunkConstants
+}
+
+// generateLuaFooter generates the statements following the table, which protect and return it
+func (le *LuaExporter) generateLuaFooter(tableName string) string {
+	switch {
+	case le.ModuleFormat && le.ReadOnly:
+		return fmt.Sprintf("\n\nreturn readonly(%s)\n", tableName)
+	case le.ModuleFormat:
+		return fmt.Sprintf("\n\nreturn %s\n", tableName)
+	case le.ReadOnly:
+		return fmt.Sprintf("\n\n%s = readonly(%s)\n", tableName, tableName)
+	default:
+		return ""
+	}
+}
+
 // generateLuaCode generates Lua code for the configuration data
 func (le *LuaExporter) generateLuaCode(store *TableStore, indentLevel int) string {
 	indent := strings.Repeat("    ", indentLevel)
@@ -144,6 +244,10 @@ func (le *LuaExporter) generateLuaCode(store *TableStore, indentLevel int) strin
 
 // generateLuaMessage generates Lua code for a protobuf message
 func (le *LuaExporter) generateLuaMessage(msg *dynamic.Message, indentLevel int) string {
+	if le.optimizer != nil {
+		return le.optimizer.generateRow(msg)
+	}
+
 	var result strings.Builder
 
 	result.WriteString("{")
@@ -159,7 +263,7 @@ func (le *LuaExporter) generateLuaMessage(msg *dynamic.Message, indentLevel int)
 				result.WriteString(", ")
 			}
 			value := msg.GetField(field)
-			result.WriteString(fmt.Sprintf("%s = %s", field.GetName(), le.formatLuaValue(value, field, indentLevel+1)))
+			result.WriteString(fmt.Sprintf("%s = %s", formatLuaName(field.GetName()), le.formatLuaValue(value, field, indentLevel+1)))
 			fieldCount++
 		}
 	} else {
@@ -168,7 +272,7 @@ func (le *LuaExporter) generateLuaMessage(msg *dynamic.Message, indentLevel int)
 		result.WriteString("\n")
 		for _, field := range fields {
 			value := msg.GetField(field)
-			result.WriteString(fmt.Sprintf("%s%s = %s", indent, field.GetName(), le.formatLuaValue(value, field, indentLevel+1)))
+			result.WriteString(fmt.Sprintf("%s%s = %s", indent, formatLuaName(field.GetName()), le.formatLuaValue(value, field, indentLevel+1)))
 			if fieldCount < len(fields)-1 {
 				result.WriteString(",")
 			}
@@ -190,13 +294,13 @@ func (le *LuaExporter) formatLuaValue(value interface{}, field *desc.FieldDescri
 
 	switch field.GetType().String() {
 	case "TYPE_STRING":
-		return fmt.Sprintf(`"%s"`, strings.ReplaceAll(value.(string), `"`, `\"`))
+		return quoteLuaString(value.(string))
 	case "TYPE_INT32", "TYPE_SINT32", "TYPE_SFIXED32", "TYPE_UINT32", "TYPE_FIXED32":
 		return fmt.Sprintf("%d", value.(int32))
 	case "TYPE_INT64", "TYPE_SINT64", "TYPE_SFIXED64", "TYPE_UINT64", "TYPE_FIXED64":
 		return fmt.Sprintf("%d", value.(int64))
 	case "TYPE_FLOAT", "TYPE_DOUBLE":
-		return fmt.Sprintf("%f", value)
+		return luaFloatLiterals.format(value)
 	case "TYPE_BOOL":
 		if value.(bool) {
 			return "true"
@@ -207,12 +311,20 @@ func (le *LuaExporter) formatLuaValue(value interface{}, field *desc.FieldDescri
 			return le.generateLuaMessage(msg, indentLevel)
 		}
 	case "TYPE_ENUM":
-		return fmt.Sprintf("%d", value.(int32))
+		return le.formatLuaEnum(value.(int32), field)
 	}
 
 	return "nil"
 }
 
+// formatLuaEnum formats an enum value as a number or a string literal depending on the enum format
+func (le *LuaExporter) formatLuaEnum(number int32, field *desc.FieldDescriptor) string {
+	if name, ok := convertEnumValue(number, field, le.EnumFormat).(string); ok {
+		return quoteLuaString(name)
+	}
+	return fmt.Sprintf("%d", number)
+}
+
 // formatLuaArray formats array values for Lua output
 func (le *LuaExporter) formatLuaArray(value interface{}, field *desc.FieldDescriptor, indentLevel int) string {
 	var result strings.Builder
@@ -254,7 +366,7 @@ func (le *LuaExporter) formatLuaArray(value interface{}, field *desc.FieldDescri
 	case "TYPE_FLOAT", "TYPE_DOUBLE":
 		result.WriteString("{")
 		for i, item := range v {
-			result.WriteString(fmt.Sprintf("%f", item))
+			result.WriteString(luaFloatLiterals.format(item))
 			if i < len(v)-1 {
 				result.WriteString(", ")
 			}
@@ -264,7 +376,7 @@ func (le *LuaExporter) formatLuaArray(value interface{}, field *desc.FieldDescri
 	case "TYPE_STRING":
 		result.WriteString("{")
 		for i, item := range v {
-			result.WriteString(fmt.Sprintf(`"%s"`, strings.ReplaceAll(item.(string), `"`, `\"`)))
+			result.WriteString(quoteLuaString(item.(string)))
 			if i < len(v)-1 {
 				result.WriteString(", ")
 			}
@@ -288,7 +400,7 @@ func (le *LuaExporter) formatLuaArray(value interface{}, field *desc.FieldDescri
 	case "TYPE_ENUM":
 		result.WriteString("{")
 		for i, item := range v {
-			result.WriteString(fmt.Sprintf("%d", item.(int32)))
+			result.WriteString(le.formatLuaEnum(item.(int32), field))
 			if i < len(v)-1 {
 				result.WriteString(", ")
 			}
@@ -348,5 +460,5 @@ func (le *LuaExporter) formatLuaKey(key StoreKey) string {
 	if key.KeyType == KeyTypeInteger {
 		return fmt.Sprintf("%d", key.IntegerValue)
 	}
-	return fmt.Sprintf(`"%s"`, key.StringValue)
+	return quoteLuaString(key.StringValue)
 }
@@ -0,0 +1,193 @@
+package protoxls
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+)
+
+// LuaAnnotationExporter exports EmmyLua / LuaLS type annotations describing the Lua output
+type LuaAnnotationExporter struct {
+	OutputDir    string // Custom output directory, defaults to DefaultOutputDir if empty
+	ModuleFormat bool   // Whether the Lua files are modules returning a local table
+	EnumFormat   string // How the Lua files export enum fields, EnumFormatNumber if empty
+
+	// Classes and aliases already written for a previous table, each type is declared once per run
+	writtenTypes map[string]bool
+}
+
+// ExportResult exports the annotations of the table and the types it uses
+func (lae *LuaAnnotationExporter) ExportResult(store *TableStore) error {
+	if lae.writtenTypes == nil {
+		lae.writtenTypes = make(map[string]bool)
+	}
+
+	keyFields, err := getTableKeyFields(store)
+	if err != nil {
+		return err
+	}
+
+	file, err := CreateOutputFile(store, lae.OutputDir, "Lua annotation")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	tableName := GetTableName(store)
+	var result strings.Builder
+	if lae.ModuleFormat {
+		// Named meta files type the value returned by require(tableName)
+		result.WriteString(fmt.Sprintf("---@meta %s\n\n", tableName))
+	} else {
+		result.WriteString("---@meta\n\n")
+	}
+
+	lae.writeMessageClass(&result, store.GetMessageDescriptor())
+
+	// Keyed tables nest one level per key, tables without keys are arrays of rows
+	tableType := luaTypeName(store.GetMessageDescriptor())
+	if len(keyFields) == 0 {
+		tableType += "[]"
+	}
+	for i := len(keyFields) - 1; i >= 0; i-- {
+		tableType = fmt.Sprintf("table<%s, %s>", luaKeyType(keyFields[i]), tableType)
+	}
+
+	result.WriteString(fmt.Sprintf("---@type %s\n", tableType))
+	if lae.ModuleFormat {
+		result.WriteString(fmt.Sprintf("local %s = {}\n\nreturn %s\n", tableName, tableName))
+	} else {
+		result.WriteString(fmt.Sprintf("%s = {}\n", tableName))
+	}
+
+	if _, err := file.WriteString(result.String()); err != nil {
+		return fmt.Errorf("failed to write Lua annotations: %v", err)
+	}
+	return nil
+}
+
+// writeMessageClass writes the class of a message after the classes and aliases of its field types
+func (lae *LuaAnnotationExporter) writeMessageClass(result *strings.Builder, msgDesc *desc.MessageDescriptor) {
+	if lae.writtenTypes[msgDesc.GetFullyQualifiedName()] {
+		return
+	}
+	lae.writtenTypes[msgDesc.GetFullyQualifiedName()] = true
+
+	for _, field := range msgDesc.GetFields() {
+		valueField := field
+		if field.IsMap() {
+			valueField = field.GetMapValueType()
+		}
+		if valueField.GetMessageType() != nil {
+			lae.writeMessageClass(result, valueField.GetMessageType())
+		}
+		if valueField.GetEnumType() != nil {
+			lae.writeEnumAlias(result, valueField.GetEnumType())
+		}
+	}
+
+	if comment := getDescriptorComment(msgDesc); comment != "" {
+		writeLuaDocComment(result, comment)
+	}
+	result.WriteString(fmt.Sprintf("---@class %s\n", luaTypeName(msgDesc)))
+	for _, field := range msgDesc.GetFields() {
+		line := fmt.Sprintf("---@field %s %s", field.GetName(), luaFieldType(field, lae.EnumFormat))
+		if description := luaFieldDescription(field); description != "" {
+			line += " " + description
+		}
+		result.WriteString(line + "\n")
+	}
+	result.WriteString("\n")
+}
+
+// writeEnumAlias writes an alias listing the enum numbers written by the Lua exporter
+func (lae *LuaAnnotationExporter) writeEnumAlias(result *strings.Builder, enumDesc *desc.EnumDescriptor) {
+	if lae.writtenTypes[enumDesc.GetFullyQualifiedName()] {
+		return
+	}
+	lae.writtenTypes[enumDesc.GetFullyQualifiedName()] = true
+
+	if comment := getDescriptorComment(enumDesc); comment != "" {
+		writeLuaDocComment(result, comment)
+	}
+	result.WriteString(fmt.Sprintf("---@alias %s\n", luaTypeName(enumDesc)))
+	displayNames := getEnumDisplayNames(enumDesc)
+	for i, enumVal := range enumDesc.GetValues() {
+		line := fmt.Sprintf("---| %d # %s", enumVal.GetNumber(), enumVal.GetName())
+		if displayNames[i] != enumVal.GetName() {
+			line += " " + displayNames[i]
+		}
+		result.WriteString(line + "\n")
+	}
+	result.WriteString("\n")
+}
+
+// writeLuaDocComment writes a proto comment as Lua documentation lines
+func writeLuaDocComment(result *strings.Builder, comment string) {
+	for _, line := range strings.Split(comment, "\n") {
+		result.WriteString("--- " + strings.TrimSpace(line) + "\n")
+	}
+}
+
+// luaTypeName returns the annotation type name of a message or enum
+func luaTypeName(descriptor desc.Descriptor) string {
+	return descriptor.GetFullyQualifiedName()
+}
+
+// luaFieldDescription returns the (text) option and comment of a field on a single line
+func luaFieldDescription(field *desc.FieldDescriptor) string {
+	var parts []string
+	if text := getFieldText(field); text != "" {
+		parts = append(parts, text)
+	}
+	if comment := getDescriptorComment(field); comment != "" {
+		parts = append(parts, strings.Join(strings.Fields(comment), " "))
+	}
+	return strings.Join(parts, " - ")
+}
+
+// luaFieldType returns the annotation type of a field, including repeated and map fields
+func luaFieldType(field *desc.FieldDescriptor, enumFormat string) string {
+	switch {
+	case field.IsMap():
+		return fmt.Sprintf("table<%s, %s>", luaValueType(field.GetMapKeyType(), enumFormat), luaValueType(field.GetMapValueType(), enumFormat))
+	case field.IsRepeated():
+		return luaValueType(field, enumFormat) + "[]"
+	default:
+		return luaValueType(field, enumFormat)
+	}
+}
+
+// luaValueType maps the type of a single field value to its annotation type
+func luaValueType(field *desc.FieldDescriptor, enumFormat string) string {
+	switch field.GetType().String() {
+	case "TYPE_INT32", "TYPE_SINT32", "TYPE_SFIXED32", "TYPE_UINT32", "TYPE_FIXED32",
+		"TYPE_INT64", "TYPE_SINT64", "TYPE_SFIXED64", "TYPE_UINT64", "TYPE_FIXED64":
+		return "integer"
+	case "TYPE_FLOAT", "TYPE_DOUBLE":
+		return "number"
+	case "TYPE_BOOL":
+		return "boolean"
+	case "TYPE_STRING", "TYPE_BYTES":
+		return "string"
+	case "TYPE_ENUM":
+		// Enums exported by name or alias are strings, the alias only lists numbers
+		if getEnumFormat(field, enumFormat) != EnumFormatNumber {
+			return "string"
+		}
+		return luaTypeName(field.GetEnumType())
+	case "TYPE_MESSAGE", "TYPE_GROUP":
+		return luaTypeName(field.GetMessageType())
+	}
+	return "any"
+}
+
+// luaKeyType returns the annotation type of the keys of a (keys) level
+func luaKeyType(field *desc.FieldDescriptor) string {
+	if isIntegerKeyField(field) {
+		return "integer"
+	}
+	// Numeric string keys are stored as integers
+	return "string|integer"
+}
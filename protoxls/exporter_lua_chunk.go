@@ -0,0 +1,131 @@
+package protoxls
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// MaxLuaChunkConstants is the default limit of constants per generated Lua function.
+	// Lua 5.1 and LuaJIT refuse to load functions with more than about 65k constants,
+	// the limit leaves room for the estimate being approximate
+	MaxLuaChunkConstants = 32768
+)
+
+// luaKeywords are identifiers that never become constants
+var luaKeywords = map[string]bool{
+	"and": true, "break": true, "do": true, "else": true, "elseif": true, "end": true,
+	"false": true, "for": true, "function": true, "goto": true, "if": true, "in": true,
+	"local": true, "nil": true, "not": true, "or": true, "repeat": true, "return": true,
+	"then": true, "true": true, "until": true, "while": true,
+}
+
+// splitLuaChunks groups consecutive entries so the estimated constants of each group stay
+// within the limit, returning the index of the first entry of every group
+func splitLuaChunks(codes []string, limit int) []int {
+	starts := []int{0}
+	constants := make(map[string]bool)
+	for i, code := range codes {
+		entryConstants := make(map[string]bool)
+		estimateLuaConstants(code, entryConstants)
+
+		added := 0
+		for constant := range entryConstants {
+			if !constants[constant] {
+				added++
+			}
+		}
+		if i > starts[len(starts)-1] && len(constants)+added > limit {
+			// An entry exceeding the limit on its own still gets a group of its own
+			starts = append(starts, i)
+			constants = make(map[string]bool)
+		}
+		for constant := range entryConstants {
+			constants[constant] = true
+		}
+	}
+	return starts
+}
+
+// estimateLuaConstants adds the string literals, numbers and names of the code to the constant set.
+// Every distinct literal and name is counted, which slightly overestimates locals and repeated names
+func estimateLuaConstants(code string, constants map[string]bool) {
+	for i := 0; i < len(code); {
+		c := code[i]
+		switch {
+		case c == '"' || c == '\'':
+			// Quoted string, skipping escaped characters
+			j := i + 1
+			for j < len(code) && code[j] != c {
+				if code[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			constants["s"+code[i:minInt(j+1, len(code))]] = true
+			i = j + 1
+		case c >= '0' && c <= '9':
+			j := i + 1
+			for j < len(code) && (isLuaNameChar(code[j]) || code[j] == '.' ||
+				((code[j] == '+' || code[j] == '-') && (code[j-1] == 'e' || code[j-1] == 'E'))) {
+				j++
+			}
+			constants["n"+code[i:j]] = true
+			i = j
+		case isLuaNameChar(c):
+			j := i + 1
+			for j < len(code) && isLuaNameChar(code[j]) {
+				j++
+			}
+			if name := code[i:j]; !luaKeywords[name] {
+				constants["k"+name] = true
+			}
+			i = j
+		default:
+			i++
+		}
+	}
+}
+
+// isLuaNameChar checks if a byte may appear in a Lua name
+func isLuaNameChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// minInt returns the smaller of two integers
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// writeLuaLoaders writes the statements as a list of functions called in order, so that each
+// group of statements is compiled as a separate function with its own constant table
+func writeLuaLoaders(writer io.StringWriter, statements []string, chunkStarts []int) error {
+	var result strings.Builder
+	result.WriteString("for _, load in ipairs({\n")
+	for i, start := range chunkStarts {
+		end := len(statements)
+		if i < len(chunkStarts)-1 {
+			end = chunkStarts[i+1]
+		}
+
+		result.WriteString("    function()\n")
+		for _, statement := range statements[start:end] {
+			result.WriteString("        " + strings.ReplaceAll(statement, "\n", "\n        ") + "\n")
+		}
+		result.WriteString("    end")
+		if i < len(chunkStarts)-1 {
+			result.WriteString(",")
+		}
+		result.WriteString("\n")
+	}
+	result.WriteString("}) do\n    load()\nend")
+
+	if _, err := writer.WriteString(result.String()); err != nil {
+		return fmt.Errorf("failed to write lua code: %v", err)
+	}
+	return nil
+}
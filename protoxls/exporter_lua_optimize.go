@@ -0,0 +1,253 @@
+package protoxls
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+)
+
+const (
+	// luaSetMetatable is the short local alias of setmetatable, called once per message table
+	luaSetMetatable = "__S"
+	// luaMetaTable is the local holding one metatable per message type, whose __index provides omitted defaults
+	luaMetaTable = "__meta"
+	// luaSharedTable is the local holding subtables referenced more than once.
+	// A single table is used instead of one local per subtable, as Lua allows at most 200 locals per function
+	luaSharedTable = "__shared"
+)
+
+// luaOptimizer generates Lua code without default-valued fields, sharing identical subtables.
+// The table is generated twice: the first pass counts subtables, the second emits references to shared ones
+type luaOptimizer struct {
+	exporter     *LuaExporter
+	counting     bool
+	subtables    map[string]int // Occurrences of each subtable by its code without sharing
+	sharedIndex  map[string]int // Index in luaSharedTable of each shared subtable
+	sharedCode   []string       // Code of the shared subtables, dependencies first
+	metaIndex    map[string]int // Index in luaMetaTable of each message type
+	messageTypes []*desc.MessageDescriptor
+}
+
+// newLuaOptimizer creates an optimizer for rows of the given message type
+func newLuaOptimizer(exporter *LuaExporter, msgDesc *desc.MessageDescriptor) *luaOptimizer {
+	optimizer := &luaOptimizer{
+		exporter:    exporter,
+		counting:    true,
+		subtables:   make(map[string]int),
+		sharedIndex: make(map[string]int),
+		metaIndex:   make(map[string]int),
+	}
+	optimizer.registerMessageType(msgDesc)
+	return optimizer
+}
+
+// registerMessageType assigns metatables to a message type and every message type reachable from it
+func (lo *luaOptimizer) registerMessageType(msgDesc *desc.MessageDescriptor) {
+	if _, exists := lo.metaIndex[msgDesc.GetFullyQualifiedName()]; exists {
+		return
+	}
+	lo.messageTypes = append(lo.messageTypes, msgDesc)
+	lo.metaIndex[msgDesc.GetFullyQualifiedName()] = len(lo.messageTypes)
+
+	for _, field := range msgDesc.GetFields() {
+		if field.GetMessageType() != nil && !field.IsMap() {
+			lo.registerMessageType(field.GetMessageType())
+		}
+	}
+}
+
+// writeOptimizedLuaTable writes the shared metatables and subtables followed by the optimized table
+func (le *LuaExporter) writeOptimizedLuaTable(writer io.StringWriter, store *TableStore, tableName, declaration string) error {
+	optimizer := newLuaOptimizer(le, store.GetMessageDescriptor())
+	le.optimizer = optimizer
+	defer func() {
+		le.optimizer = nil
+	}()
+
+	// First pass only counts subtables
+	if err := le.writeLuaTable(&strings.Builder{}, store, tableName, declaration); err != nil {
+		return err
+	}
+
+	optimizer.counting = false
+	var body strings.Builder
+	if err := le.writeLuaTable(&body, store, tableName, declaration); err != nil {
+		return err
+	}
+
+	if err := optimizer.writePrelude(writer); err != nil {
+		return err
+	}
+	if _, err := writer.WriteString(body.String()); err != nil {
+		return fmt.Errorf("failed to write lua code: %v", err)
+	}
+	return nil
+}
+
+// writePrelude writes the metatables with defaults and the shared subtables
+func (lo *luaOptimizer) writePrelude(writer io.StringWriter) error {
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("local %s = setmetatable\n\n", luaSetMetatable))
+
+	// Metatables are created before the defaults, which may refer to other message types
+	result.WriteString("-- Default values of omitted fields\n")
+	result.WriteString(fmt.Sprintf("local %s = {}\n", luaMetaTable))
+	for i, msgDesc := range lo.messageTypes {
+		result.WriteString(fmt.Sprintf("%s[%d] = {} -- %s\n", luaMetaTable, i+1, msgDesc.GetFullyQualifiedName()))
+	}
+	for i, msgDesc := range lo.messageTypes {
+		result.WriteString(fmt.Sprintf("%s[%d].__index = %s\n", luaMetaTable, i+1, lo.generateDefaults(msgDesc)))
+	}
+	if lo.exporter.ReadOnly {
+		for i := range lo.messageTypes {
+			result.WriteString(fmt.Sprintf("%s[%d].__index = readonly(%s[%d].__index)\n", luaMetaTable, i+1, luaMetaTable, i+1))
+		}
+	}
+	result.WriteString("\n")
+
+	if len(lo.sharedCode) > 0 {
+		result.WriteString("-- Subtables referenced more than once\n")
+		result.WriteString(fmt.Sprintf("local %s = {}\n", luaSharedTable))
+	}
+	if _, err := writer.WriteString(result.String()); err != nil {
+		return fmt.Errorf("failed to write lua code: %v", err)
+	}
+	if len(lo.sharedCode) == 0 {
+		return nil
+	}
+
+	statements := make([]string, len(lo.sharedCode))
+	for i, code := range lo.sharedCode {
+		statements[i] = fmt.Sprintf("%s[%d] = %s", luaSharedTable, i+1, code)
+	}
+	if chunkStarts := splitLuaChunks(statements, lo.exporter.chunkConstants()); len(chunkStarts) > 1 {
+		if err := writeLuaLoaders(writer, statements, chunkStarts); err != nil {
+			return err
+		}
+	} else if _, err := writer.WriteString(strings.Join(statements, "\n")); err != nil {
+		return fmt.Errorf("failed to write lua code: %v", err)
+	}
+	if _, err := writer.WriteString("\n\n"); err != nil {
+		return fmt.Errorf("failed to write lua code: %v", err)
+	}
+	return nil
+}
+
+// generateDefaults generates the table of default values of a message type
+func (lo *luaOptimizer) generateDefaults(msgDesc *desc.MessageDescriptor) string {
+	var fields []string
+	for _, field := range msgDesc.GetFields() {
+		var code string
+		switch {
+		case field.IsRepeated():
+			code = "{}"
+		case field.GetMessageType() != nil:
+			code = fmt.Sprintf("%s({}, %s)", luaSetMetatable, lo.metaReference(field.GetMessageType()))
+		default:
+			code = lo.exporter.formatLuaValue(field.GetDefaultValue(), field, 0)
+		}
+		fields = append(fields, fmt.Sprintf("%s = %s", formatLuaName(field.GetName()), code))
+	}
+	return "{" + strings.Join(fields, ", ") + "}"
+}
+
+// metaReference returns the expression of the metatable of a message type
+func (lo *luaOptimizer) metaReference(msgDesc *desc.MessageDescriptor) string {
+	return fmt.Sprintf("%s[%d]", luaMetaTable, lo.metaIndex[msgDesc.GetFullyQualifiedName()])
+}
+
+// generateRow generates the code of a row, which is never shared
+func (lo *luaOptimizer) generateRow(msg *dynamic.Message) string {
+	_, code := lo.generateMessage(msg)
+	return code
+}
+
+// generateMessage generates a message without its default-valued fields, returning both the
+// code without sharing, which identifies the subtable, and the code to emit
+func (lo *luaOptimizer) generateMessage(msg *dynamic.Message) (string, string) {
+	var plainFields, fields []string
+	for _, field := range msg.GetMessageDescriptor().GetFields() {
+		plain, code, isDefault := lo.generateField(msg.GetField(field), field)
+		if isDefault {
+			continue
+		}
+		name := formatLuaName(field.GetName())
+		plainFields = append(plainFields, fmt.Sprintf("%s = %s", name, plain))
+		fields = append(fields, fmt.Sprintf("%s = %s", name, code))
+	}
+
+	meta := lo.metaReference(msg.GetMessageDescriptor())
+	plain := fmt.Sprintf("%s({%s}, %s)", luaSetMetatable, strings.Join(plainFields, ", "), meta)
+	code := fmt.Sprintf("%s({%s}, %s)", luaSetMetatable, strings.Join(fields, ", "), meta)
+	return plain, code
+}
+
+// generateField generates the value of a field and reports whether it equals the default
+func (lo *luaOptimizer) generateField(value interface{}, field *desc.FieldDescriptor) (string, string, bool) {
+	switch {
+	case field.IsMap():
+		code := lo.exporter.formatLuaValue(value, field, 0)
+		return code, code, false
+	case field.IsRepeated():
+		items, _ := value.([]interface{})
+		if len(items) == 0 {
+			return "{}", "{}", true
+		}
+		if field.GetMessageType() == nil {
+			code := lo.exporter.formatLuaArray(value, field, 0)
+			return code, lo.share(code, code), false
+		}
+
+		plainItems := make([]string, len(items))
+		codeItems := make([]string, len(items))
+		for i, item := range items {
+			if msg, ok := item.(*dynamic.Message); ok {
+				plainItems[i], codeItems[i] = lo.generateMessage(msg)
+				codeItems[i] = lo.share(plainItems[i], codeItems[i])
+			} else {
+				plainItems[i], codeItems[i] = "nil", "nil"
+			}
+		}
+		plain := "{" + strings.Join(plainItems, ", ") + "}"
+		return plain, lo.share(plain, "{"+strings.Join(codeItems, ", ")+"}"), false
+	case field.GetMessageType() != nil:
+		msg, ok := value.(*dynamic.Message)
+		if !ok || msg == nil {
+			return "nil", "nil", true
+		}
+		plain, code := lo.generateMessage(msg)
+		// A message without explicit fields reads the same as the default empty message
+		if plain == fmt.Sprintf("%s({}, %s)", luaSetMetatable, lo.metaReference(field.GetMessageType())) {
+			return plain, code, true
+		}
+		return plain, lo.share(plain, code), false
+	default:
+		code := lo.exporter.formatLuaValue(value, field, 0)
+		defaultCode := lo.exporter.formatLuaValue(field.GetDefaultValue(), field, 0)
+		return code, code, code == defaultCode
+	}
+}
+
+// share counts a subtable in the first pass, and in the second pass replaces subtables
+// occurring more than once with a reference into the shared table
+func (lo *luaOptimizer) share(plain, code string) string {
+	if lo.counting {
+		lo.subtables[plain]++
+		return code
+	}
+	if lo.subtables[plain] < 2 {
+		return code
+	}
+
+	index, exists := lo.sharedIndex[plain]
+	if !exists {
+		// Nested shared subtables were added while generating code, so dependencies come first
+		lo.sharedCode = append(lo.sharedCode, code)
+		index = len(lo.sharedCode)
+		lo.sharedIndex[plain] = index
+	}
+	return fmt.Sprintf("%s[%d]", luaSharedTable, index)
+}
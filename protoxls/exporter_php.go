@@ -2,7 +2,6 @@ package protoxls
 
 import (
 	"fmt"
-	"sort"
 	"strings"
 
 	"github.com/jhump/protoreflect/desc"
@@ -12,7 +11,9 @@ import (
 // PhpExporter exports data to PHP format
 type PhpExporter struct {
 	OutputDir     string
-	CompactFormat bool // Whether to compress each data entry to a single line
+	CompactFormat bool   // Whether to compress each data entry to a single line
+	EnumStyle     string // How enum definitions are written, PhpEnumStyleClass if empty
+	EnumFormat    string // How enum fields are exported, EnumFormatNumber if empty
 }
 
 // ExportResult exports the table store data to a PHP file
@@ -157,12 +158,8 @@ func (e *PhpExporter) generatePhpMessage(msg *dynamic.Message, indentLevel int)
 	result.WriteString("[")
 
 	descriptor := msg.GetMessageDescriptor()
-	fields := descriptor.GetFields()
-	
 	// Sort fields by field number to maintain proto definition order (consistent with JSON exporter)
-	sort.Slice(fields, func(i, j int) bool {
-		return fields[i].GetNumber() < fields[j].GetNumber()
-	})
+	fields := getSortedFields(descriptor)
 
 	fieldCount := 0
 	if e.CompactFormat {
@@ -203,16 +200,13 @@ func (e *PhpExporter) formatPhpValue(value interface{}, field *desc.FieldDescrip
 
 	switch field.GetType().String() {
 	case "TYPE_STRING":
-		// Escape quotes and backslashes in strings
-		escaped := strings.ReplaceAll(value.(string), "\\", "\\\\")
-		escaped = strings.ReplaceAll(escaped, "'", "\\'")
-		return fmt.Sprintf("'%s'", escaped)
+		return quotePhpString(value.(string))
 	case "TYPE_INT32", "TYPE_SINT32", "TYPE_SFIXED32", "TYPE_UINT32", "TYPE_FIXED32":
 		return fmt.Sprintf("%d", value.(int32))
 	case "TYPE_INT64", "TYPE_SINT64", "TYPE_SFIXED64", "TYPE_UINT64", "TYPE_FIXED64":
 		return fmt.Sprintf("%d", value.(int64))
 	case "TYPE_FLOAT", "TYPE_DOUBLE":
-		return fmt.Sprintf("%g", value)
+		return phpFloatLiterals.format(value)
 	case "TYPE_BOOL":
 		if value.(bool) {
 			return "true"
@@ -223,12 +217,20 @@ func (e *PhpExporter) formatPhpValue(value interface{}, field *desc.FieldDescrip
 			return e.generatePhpMessage(msg, indentLevel)
 		}
 	case "TYPE_ENUM":
-		return fmt.Sprintf("%d", value.(int32))
+		return e.formatPhpEnum(value.(int32), field)
 	}
 
 	return "null"
 }
 
+// formatPhpEnum formats an enum value as a number or a string literal depending on the enum format
+func (e *PhpExporter) formatPhpEnum(number int32, field *desc.FieldDescriptor) string {
+	if name, ok := convertEnumValue(number, field, e.EnumFormat).(string); ok {
+		return quotePhpString(name)
+	}
+	return fmt.Sprintf("%d", number)
+}
+
 // formatPhpArray formats array values for PHP output
 func (e *PhpExporter) formatPhpArray(value interface{}, field *desc.FieldDescriptor, indentLevel int) string {
 	v, ok := value.([]interface{})
@@ -269,7 +271,7 @@ func (e *PhpExporter) formatPhpArray(value interface{}, field *desc.FieldDescrip
 	case "TYPE_FLOAT", "TYPE_DOUBLE":
 		result.WriteString("[")
 		for i, item := range v {
-			result.WriteString(fmt.Sprintf("%g", item))
+			result.WriteString(phpFloatLiterals.format(item))
 			if i < len(v)-1 {
 				result.WriteString(", ")
 			}
@@ -279,9 +281,7 @@ func (e *PhpExporter) formatPhpArray(value interface{}, field *desc.FieldDescrip
 	case "TYPE_STRING":
 		result.WriteString("[")
 		for i, item := range v {
-			escaped := strings.ReplaceAll(item.(string), "\\", "\\\\")
-			escaped = strings.ReplaceAll(escaped, "'", "\\'")
-			result.WriteString(fmt.Sprintf("'%s'", escaped))
+			result.WriteString(quotePhpString(item.(string)))
 			if i < len(v)-1 {
 				result.WriteString(", ")
 			}
@@ -305,7 +305,7 @@ func (e *PhpExporter) formatPhpArray(value interface{}, field *desc.FieldDescrip
 	case "TYPE_ENUM":
 		result.WriteString("[")
 		for i, item := range v {
-			result.WriteString(fmt.Sprintf("%d", item.(int32)))
+			result.WriteString(e.formatPhpEnum(item.(int32), field))
 			if i < len(v)-1 {
 				result.WriteString(", ")
 			}
@@ -365,5 +365,5 @@ func (e *PhpExporter) formatPhpKey(key StoreKey) string {
 	if key.KeyType == KeyTypeInteger {
 		return fmt.Sprintf("'%d'", key.IntegerValue)
 	}
-	return fmt.Sprintf("'%s'", strings.ReplaceAll(key.StringValue, "'", "\\'"))
+	return quotePhpString(key.StringValue)
 }
\ No newline at end of file
@@ -0,0 +1,518 @@
+package protoxls
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+)
+
+const (
+	// PyTypesDataclass defines messages as dataclasses, rows are instances of them
+	PyTypesDataclass = "dataclass"
+	// PyTypesTypedDict defines messages as TypedDicts, rows are plain dicts
+	PyTypesTypedDict = "typeddict"
+
+	// pyIndent is the indentation of the generated Python code
+	pyIndent = "    "
+	// pyPackageFileName is the base name of the file making the output directory a package
+	pyPackageFileName = "__init__"
+)
+
+// pyKeywords are the reserved words of Python 3, which cannot name fields or enum members
+var pyKeywords = map[string]bool{
+	"False": true, "None": true, "True": true, "and": true, "as": true, "assert": true, "async": true,
+	"await": true, "break": true, "class": true, "continue": true, "def": true, "del": true, "elif": true,
+	"else": true, "except": true, "finally": true, "for": true, "from": true, "global": true, "if": true,
+	"import": true, "in": true, "is": true, "lambda": true, "nonlocal": true, "not": true, "or": true,
+	"pass": true, "raise": true, "return": true, "try": true, "while": true, "with": true, "yield": true,
+}
+
+// IsValidPyTypes checks if a string is one of the supported Python type definition styles
+func IsValidPyTypes(types string) bool {
+	return types == PyTypesDataclass || types == PyTypesTypedDict
+}
+
+// PyExporter exports each table as an importable Python module defining a class per message and an IntEnum
+// per enum, with the rows as literal dicts nested along the (keys) hierarchy, or a list for tables without keys
+type PyExporter struct {
+	OutputDir string // Custom output directory, defaults to DefaultOutputDir if empty
+	Types     string // PyTypesDataclass or PyTypesTypedDict, PyTypesDataclass if empty
+
+	// Module of each type defined for a previous table, each type is defined once and imported by later tables
+	typeModules    map[string]string
+	packageWritten bool
+}
+
+// ExportResult writes the module of a table with the types it uses that no previous table defined
+func (pe *PyExporter) ExportResult(store *TableStore) error {
+	if pe.typeModules == nil {
+		pe.typeModules = make(map[string]string)
+	}
+	// Types are imported relative to the package, so the output directory must be one
+	if !pe.packageWritten {
+		if err := pe.writePackage(); err != nil {
+			return err
+		}
+		pe.packageWritten = true
+	}
+	keyFields, err := getTableKeyFields(store)
+	if err != nil {
+		return err
+	}
+
+	msgDesc := store.GetMessageDescriptor()
+	module := GetTableName(store)
+	newTypes := pe.collectNewTypes(msgDesc, module)
+
+	var body strings.Builder
+	hasEnums := false
+	for _, typeDesc := range newTypes {
+		if enumDesc, ok := typeDesc.(*desc.EnumDescriptor); ok {
+			pe.writeEnum(&body, enumDesc)
+			hasEnums = true
+		} else {
+			pe.writeClass(&body, typeDesc.(*desc.MessageDescriptor))
+		}
+	}
+	usesMath := pe.writeRows(&body, store, keyFields)
+
+	var code strings.Builder
+	code.WriteString(fmt.Sprintf("# Code generated by protoxls from %s. DO NOT EDIT.\n", msgDesc.GetFile().GetName()))
+	code.WriteString("from __future__ import annotations\n\n")
+	if pe.Types != PyTypesTypedDict {
+		code.WriteString("import dataclasses\n")
+	}
+	if hasEnums {
+		code.WriteString("import enum\n")
+	}
+	if usesMath {
+		code.WriteString("import math\n")
+	}
+	code.WriteString("import typing\n")
+	pe.writeImports(&code, msgDesc, module)
+	code.WriteString(body.String())
+
+	file, err := CreateOutputFile(store, pe.OutputDir, "Python")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if _, err := file.WriteString(code.String()); err != nil {
+		return fmt.Errorf("failed to write Python module: %v", err)
+	}
+	return nil
+}
+
+// writePackage writes the file making the output directory a package
+func (pe *PyExporter) writePackage() error {
+	file, err := CreateNamedOutputFile(pyPackageFileName, pe.OutputDir, "Python")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if _, err := file.WriteString("# Code generated by protoxls. DO NOT EDIT.\n"); err != nil {
+		return fmt.Errorf("failed to write Python package: %v", err)
+	}
+	return nil
+}
+
+// collectNewTypes returns the messages and enums used by a row message that are not defined yet, the row
+// message first, and records them as defined by the module of the table. Nested types are defined with
+// their message
+func (pe *PyExporter) collectNewTypes(msgDesc *desc.MessageDescriptor, module string) []desc.Descriptor {
+	var types []desc.Descriptor
+
+	var visitType func(typeDesc desc.Descriptor)
+	visitType = func(typeDesc desc.Descriptor) {
+		if _, written := pe.typeModules[typeDesc.GetFullyQualifiedName()]; written {
+			return
+		}
+		pe.typeModules[typeDesc.GetFullyQualifiedName()] = module
+		types = append(types, typeDesc)
+
+		msgDesc, ok := typeDesc.(*desc.MessageDescriptor)
+		if !ok {
+			return
+		}
+		for _, field := range msgDesc.GetFields() {
+			if field.IsMap() {
+				field = field.GetMapValueType()
+			}
+			if field.GetMessageType() != nil {
+				visitType(field.GetMessageType())
+			}
+			if field.GetEnumType() != nil {
+				visitType(field.GetEnumType())
+			}
+		}
+		for _, nested := range msgDesc.GetNestedMessageTypes() {
+			if !nested.IsMapEntry() {
+				visitType(nested)
+			}
+		}
+		for _, nested := range msgDesc.GetNestedEnumTypes() {
+			visitType(nested)
+		}
+	}
+
+	visitType(msgDesc)
+	return types
+}
+
+// writeImports imports the types defined by previous tables that the module refers to, which are all the types
+// reachable from the row message since the rows spell out nested messages and enum values
+func (pe *PyExporter) writeImports(code *strings.Builder, msgDesc *desc.MessageDescriptor, module string) {
+	importedTypes := make(map[string][]string)
+	seen := make(map[string]bool)
+
+	var visitType func(typeDesc desc.Descriptor)
+	visitType = func(typeDesc desc.Descriptor) {
+		if seen[typeDesc.GetFullyQualifiedName()] {
+			return
+		}
+		seen[typeDesc.GetFullyQualifiedName()] = true
+		if typeModule := pe.typeModules[typeDesc.GetFullyQualifiedName()]; typeModule != module {
+			importedTypes[typeModule] = append(importedTypes[typeModule], pyTypeName(typeDesc))
+		}
+
+		msgDesc, ok := typeDesc.(*desc.MessageDescriptor)
+		if !ok {
+			return
+		}
+		for _, field := range msgDesc.GetFields() {
+			if field.IsMap() {
+				field = field.GetMapValueType()
+			}
+			if field.GetMessageType() != nil {
+				visitType(field.GetMessageType())
+			}
+			if field.GetEnumType() != nil {
+				visitType(field.GetEnumType())
+			}
+		}
+	}
+	visitType(msgDesc)
+
+	modules := make([]string, 0, len(importedTypes))
+	for typeModule := range importedTypes {
+		modules = append(modules, typeModule)
+	}
+	sort.Strings(modules)
+	if len(modules) > 0 {
+		code.WriteString("\n")
+	}
+	for _, typeModule := range modules {
+		names := importedTypes[typeModule]
+		sort.Strings(names)
+		code.WriteString(fmt.Sprintf("from .%s import %s\n", typeModule, strings.Join(names, ", ")))
+	}
+}
+
+// writeClass writes the dataclass or TypedDict of a message
+func (pe *PyExporter) writeClass(code *strings.Builder, msgDesc *desc.MessageDescriptor) {
+	typeName := pyTypeName(msgDesc)
+	fields := getSortedFields(msgDesc)
+	code.WriteString("\n\n")
+	writePyComment(code, "", []string{getDescriptorComment(msgDesc)})
+
+	if pe.Types == PyTypesTypedDict {
+		// Keys that are reserved words can only be declared with the functional syntax, which evaluates
+		// the types, so they are quoted as forward references
+		for _, field := range fields {
+			if pyKeywords[field.GetName()] {
+				code.WriteString(fmt.Sprintf("%s = typing.TypedDict(%s, {\n", typeName, quotePythonString(typeName)))
+				for _, field := range fields {
+					writePyComment(code, pyIndent, getFieldDescription(field))
+					code.WriteString(fmt.Sprintf("%s%s: %s,\n", pyIndent, quotePythonString(field.GetName()), quotePythonString(pe.fieldType(field))))
+				}
+				code.WriteString("})\n")
+				return
+			}
+		}
+		code.WriteString(fmt.Sprintf("class %s(typing.TypedDict):\n", typeName))
+	} else {
+		code.WriteString(fmt.Sprintf("@dataclasses.dataclass\nclass %s:\n", typeName))
+	}
+
+	if len(fields) == 0 {
+		code.WriteString(pyIndent + "pass\n")
+	}
+	for _, field := range fields {
+		writePyComment(code, pyIndent, getFieldDescription(field))
+		code.WriteString(fmt.Sprintf("%s%s: %s\n", pyIndent, pe.fieldName(field), pe.fieldType(field)))
+	}
+}
+
+// writeEnum writes the IntEnum of an enum, values sharing a number (allow_alias) become aliases of the first
+func (pe *PyExporter) writeEnum(code *strings.Builder, enumDesc *desc.EnumDescriptor) {
+	code.WriteString("\n\n")
+	writePyComment(code, "", []string{getDescriptorComment(enumDesc)})
+	code.WriteString(fmt.Sprintf("class %s(enum.IntEnum):\n", pyTypeName(enumDesc)))
+	for _, enumVal := range enumDesc.GetValues() {
+		var description []string
+		if displayName := getEnumDisplayName(enumVal); displayName != enumVal.GetName() {
+			description = append(description, displayName)
+		}
+		writePyComment(code, pyIndent, append(description, getDescriptorComment(enumVal)))
+		code.WriteString(fmt.Sprintf("%s%s = %d\n", pyIndent, pyEnumMemberName(enumVal), enumVal.GetNumber()))
+	}
+}
+
+// writeRows writes the table type and the rows of a table, and returns whether the rows use the math module
+func (pe *PyExporter) writeRows(code *strings.Builder, store *TableStore, keyFields []*desc.FieldDescriptor) bool {
+	msgDesc := store.GetMessageDescriptor()
+	rowType := pyTypeName(msgDesc)
+	tableType := rowType + "Table"
+
+	keyNames := make([]string, len(keyFields))
+	valueType := rowType
+	for i := len(keyFields) - 1; i >= 0; i-- {
+		keyNames[i] = keyFields[i].GetName()
+		valueType = fmt.Sprintf("typing.Dict[%s, %s]", pe.valueType(keyFields[i]), valueType)
+	}
+	if len(keyFields) == 0 {
+		valueType = fmt.Sprintf("typing.List[%s]", rowType)
+	}
+
+	code.WriteString("\n\n")
+	if len(keyFields) > 0 {
+		writePyComment(code, "", []string{fmt.Sprintf("Rows of %s by %s", GetTableName(store), strings.Join(keyNames, ", "))})
+	} else {
+		writePyComment(code, "", []string{fmt.Sprintf("Rows of %s in sheet order", GetTableName(store))})
+	}
+	code.WriteString(fmt.Sprintf("%s = %s\n\n", tableType, valueType))
+
+	writer := &pyValueWriter{exporter: pe}
+	var rows string
+	if len(keyFields) > 0 {
+		rows = writer.formatKeyLevel(store, keyFields, 0)
+	} else {
+		var items []string
+		for _, message := range store.GetAllMessages() {
+			items = append(items, writer.formatMessage(message, 1))
+		}
+		rows = formatPyCollection("[", "]", items, 0)
+	}
+	code.WriteString(fmt.Sprintf("%s: %s = %s\n", pyConstantName(rowType), tableType, rows))
+	return writer.usesMath
+}
+
+// fieldName returns the attribute of a field, reserved words get a trailing underscore in dataclasses
+func (pe *PyExporter) fieldName(field *desc.FieldDescriptor) string {
+	if pe.Types != PyTypesTypedDict && pyKeywords[field.GetName()] {
+		return field.GetName() + "_"
+	}
+	return field.GetName()
+}
+
+// fieldType returns the annotation of a field, including repeated and map fields
+func (pe *PyExporter) fieldType(field *desc.FieldDescriptor) string {
+	switch {
+	case field.IsMap():
+		return fmt.Sprintf("typing.Dict[%s, %s]", pe.valueType(field.GetMapKeyType()), pe.valueType(field.GetMapValueType()))
+	case field.IsRepeated():
+		return fmt.Sprintf("typing.List[%s]", pe.valueType(field))
+	case field.GetMessageType() != nil:
+		// Unset message fields are None
+		return fmt.Sprintf("typing.Optional[%s]", pe.valueType(field))
+	default:
+		return pe.valueType(field)
+	}
+}
+
+// valueType maps the type of a single field value to its Python type
+func (pe *PyExporter) valueType(field *desc.FieldDescriptor) string {
+	switch field.GetType().String() {
+	case "TYPE_MESSAGE", "TYPE_GROUP":
+		return pyTypeName(field.GetMessageType())
+	case "TYPE_ENUM":
+		return pyTypeName(field.GetEnumType())
+	case "TYPE_FLOAT", "TYPE_DOUBLE":
+		return "float"
+	case "TYPE_BOOL":
+		return "bool"
+	case "TYPE_STRING":
+		return "str"
+	case "TYPE_BYTES":
+		return "bytes"
+	default:
+		return "int"
+	}
+}
+
+// pyValueWriter formats field values as Python literals, one element per line for messages and collections
+type pyValueWriter struct {
+	exporter *PyExporter
+	usesMath bool // Whether a non-finite float was written, which is spelled with the math module
+}
+
+// formatKeyLevel formats the rows below a key level as a dict keyed by the values of the key field
+func (pw *pyValueWriter) formatKeyLevel(store *TableStore, keyFields []*desc.FieldDescriptor, depth int) string {
+	var items []string
+	for _, key := range store.GetAllKeys() {
+		childStore := store.GetChildStore(key)
+		message := childStore.GetFirstMessage()
+		if message == nil {
+			continue
+		}
+		// The store keys are strings or integers, the literal keys keep the type of the field
+		keyLiteral := pw.formatScalar(message.GetField(keyFields[0]), keyFields[0])
+		var value string
+		if len(keyFields) > 1 {
+			value = pw.formatKeyLevel(childStore, keyFields[1:], depth+1)
+		} else {
+			value = pw.formatMessage(message, depth+1)
+		}
+		items = append(items, keyLiteral+": "+value)
+	}
+	return formatPyCollection("{", "}", items, depth)
+}
+
+// formatMessage formats a message as a dataclass instance or a dict, with every field present
+func (pw *pyValueWriter) formatMessage(msg *dynamic.Message, depth int) string {
+	fields := getSortedFields(msg.GetMessageDescriptor())
+
+	items := make([]string, 0, len(fields))
+	for _, field := range fields {
+		value := pw.formatField(msg, field, depth+1)
+		if pw.exporter.Types == PyTypesTypedDict {
+			items = append(items, quotePythonString(field.GetName())+": "+value)
+		} else {
+			items = append(items, pw.exporter.fieldName(field)+"="+value)
+		}
+	}
+	if pw.exporter.Types == PyTypesTypedDict {
+		return formatPyCollection("{", "}", items, depth)
+	}
+	return formatPyCollection(pyTypeName(msg.GetMessageDescriptor())+"(", ")", items, depth)
+}
+
+// formatField formats the value of a field, including repeated and map fields
+func (pw *pyValueWriter) formatField(msg *dynamic.Message, field *desc.FieldDescriptor, depth int) string {
+	value := msg.GetField(field)
+	switch {
+	case field.IsMap():
+		entries, _ := value.(map[interface{}]interface{})
+		keys := make([]interface{}, 0, len(entries))
+		for key := range entries {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return lessMapKey(keys[i], keys[j])
+		})
+		items := make([]string, 0, len(keys))
+		for _, key := range keys {
+			items = append(items, pw.formatScalar(key, field.GetMapKeyType())+": "+pw.formatValue(entries[key], field.GetMapValueType(), depth+1))
+		}
+		return formatPyCollection("{", "}", items, depth)
+	case field.IsRepeated():
+		values, _ := value.([]interface{})
+		items := make([]string, 0, len(values))
+		for _, item := range values {
+			items = append(items, pw.formatValue(item, field, depth+1))
+		}
+		// Lists of scalars stay on one line
+		if field.GetMessageType() == nil {
+			return "[" + strings.Join(items, ", ") + "]"
+		}
+		return formatPyCollection("[", "]", items, depth)
+	case field.GetMessageType() != nil && !msg.HasField(field):
+		return "None"
+	default:
+		return pw.formatValue(value, field, depth)
+	}
+}
+
+// formatValue formats a single value of a field
+func (pw *pyValueWriter) formatValue(value interface{}, field *desc.FieldDescriptor, depth int) string {
+	if msg, ok := value.(*dynamic.Message); ok {
+		return pw.formatMessage(msg, depth)
+	}
+	return pw.formatScalar(value, field)
+}
+
+// formatScalar formats a scalar or enum value as a Python literal
+func (pw *pyValueWriter) formatScalar(value interface{}, field *desc.FieldDescriptor) string {
+	switch v := value.(type) {
+	case string:
+		return quotePythonString(v)
+	case []byte:
+		return quotePythonBytes(v)
+	case bool:
+		if v {
+			return "True"
+		}
+		return "False"
+	case float32, float64:
+		literal := pythonFloatLiterals.format(v)
+		if strings.Contains(literal, "math.") {
+			pw.usesMath = true
+		}
+		return literal
+	case int32:
+		if enumDesc := field.GetEnumType(); enumDesc != nil {
+			// Unknown numbers are no members of the IntEnum and are written as plain integers
+			if enumVal := enumDesc.FindValueByNumber(v); enumVal != nil {
+				return pyTypeName(enumDesc) + "." + pyEnumMemberName(enumVal)
+			}
+		}
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// formatPyCollection formats the items of a literal one per line with trailing commas, empty literals on one line
+func formatPyCollection(open, close string, items []string, depth int) string {
+	if len(items) == 0 {
+		return open + close
+	}
+	indent := strings.Repeat(pyIndent, depth)
+	var result strings.Builder
+	result.WriteString(open + "\n")
+	for _, item := range items {
+		result.WriteString(indent + pyIndent + item + ",\n")
+	}
+	result.WriteString(indent + close)
+	return result.String()
+}
+
+// pyTypeName returns the Python name of a message or enum, nested types joined to their parents by underscores
+func pyTypeName(typeDesc desc.Descriptor) string {
+	return tsTypeName(typeDesc)
+}
+
+// pyEnumMemberName returns the IntEnum member of an enum value, reserved words get a trailing underscore
+func pyEnumMemberName(enumVal *desc.EnumValueDescriptor) string {
+	if pyKeywords[enumVal.GetName()] {
+		return enumVal.GetName() + "_"
+	}
+	return enumVal.GetName()
+}
+
+// pyConstantName converts a CamelCase type name to the UPPER_SNAKE_CASE name of its rows
+func pyConstantName(typeName string) string {
+	runes := []rune(typeName)
+	var result strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && runes[i-1] != '_' &&
+			(unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			result.WriteByte('_')
+		}
+		result.WriteRune(unicode.ToUpper(r))
+	}
+	return result.String()
+}
+
+// writePyComment writes the non-empty lines of a description as comments, nothing if all are empty
+func writePyComment(code *strings.Builder, indent string, description []string) {
+	for _, text := range description {
+		for _, line := range strings.Split(text, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				code.WriteString(fmt.Sprintf("%s# %s\n", indent, line))
+			}
+		}
+	}
+}
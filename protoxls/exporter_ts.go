@@ -0,0 +1,427 @@
+package protoxls
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+)
+
+const (
+	// TsModeTypes writes only the declarations, typing the JSON output loaded at runtime
+	TsModeTypes = "types"
+	// TsModeModule also writes an ES module per table exporting the data as a typed constant
+	TsModeModule = "module"
+
+	// tsFloatType is the type of float values, non-finite values are written as strings in both JSON styles
+	tsFloatType = `number | "NaN" | "Infinity" | "-Infinity"`
+)
+
+// IsValidTsMode checks if a string is one of the supported TypeScript output modes
+func IsValidTsMode(mode string) bool {
+	return mode == TsModeTypes || mode == TsModeModule
+}
+
+// TsExporter generates TypeScript declarations matching the JSON output: an interface per message, a const
+// enum per enum and a table type following the (keys) hierarchy, and optionally the data as ES modules
+type TsExporter struct {
+	OutputDir  string // Custom output directory, defaults to DefaultOutputDir if empty
+	Mode       string // TsModeTypes or TsModeModule, TsModeTypes if empty
+	JsonStyle  string // JSON mapping style of the typed output, JsonStyleDefault if empty
+	EnumFormat string // How the typed output exports enum fields, EnumFormatNumber if empty
+
+	// Declaration file of each type written for a previous table, each type is declared once and imported by later tables
+	typeModules map[string]string
+}
+
+// ExportResult writes the declarations of the table and the types it uses that no previous table declared
+func (te *TsExporter) ExportResult(store *TableStore) error {
+	if te.typeModules == nil {
+		te.typeModules = make(map[string]string)
+	}
+	keyFields, err := getTableKeyFields(store)
+	if err != nil {
+		return err
+	}
+
+	msgDesc := store.GetMessageDescriptor()
+	module := GetTableName(store)
+	newTypes := te.collectNewTypes(msgDesc, module)
+
+	var body strings.Builder
+	for _, typeDesc := range newTypes {
+		if enumDesc, ok := typeDesc.(*desc.EnumDescriptor); ok {
+			te.writeEnum(&body, enumDesc)
+		} else {
+			te.writeInterface(&body, typeDesc.(*desc.MessageDescriptor))
+		}
+	}
+	tableType := te.writeTableType(&body, store, keyFields)
+
+	var code strings.Builder
+	code.WriteString(fmt.Sprintf("// Code generated by protoxls from %s. DO NOT EDIT.\n", msgDesc.GetFile().GetName()))
+	te.writeImports(&code, msgDesc, newTypes, module)
+	code.WriteString(body.String())
+
+	file, err := CreateOutputFile(store, te.OutputDir, "TypeScript declaration")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if _, err := file.WriteString(code.String()); err != nil {
+		return fmt.Errorf("failed to write TypeScript declarations: %v", err)
+	}
+
+	if te.Mode == TsModeModule {
+		return te.writeDataModule(store, module, tableType)
+	}
+	return nil
+}
+
+// collectNewTypes returns the messages and enums used by a row message that are not declared yet, the row
+// message first, and records them as declared by the module of the table. Nested types are declared with
+// their message. Well-known types written as plain JSON values in the protojson style are not declared
+func (te *TsExporter) collectNewTypes(msgDesc *desc.MessageDescriptor, module string) []desc.Descriptor {
+	var types []desc.Descriptor
+
+	var visitType func(typeDesc desc.Descriptor)
+	visitType = func(typeDesc desc.Descriptor) {
+		if _, written := te.typeModules[typeDesc.GetFullyQualifiedName()]; written {
+			return
+		}
+		if msgDesc, ok := typeDesc.(*desc.MessageDescriptor); ok && te.wellKnownType(msgDesc) != "" {
+			return
+		}
+		te.typeModules[typeDesc.GetFullyQualifiedName()] = module
+		types = append(types, typeDesc)
+
+		msgDesc, ok := typeDesc.(*desc.MessageDescriptor)
+		if !ok {
+			return
+		}
+		for _, field := range msgDesc.GetFields() {
+			if field.IsMap() {
+				field = field.GetMapValueType()
+			}
+			if field.GetMessageType() != nil {
+				visitType(field.GetMessageType())
+			}
+			if field.GetEnumType() != nil {
+				visitType(field.GetEnumType())
+			}
+		}
+		for _, nested := range msgDesc.GetNestedMessageTypes() {
+			if !nested.IsMapEntry() {
+				visitType(nested)
+			}
+		}
+		for _, nested := range msgDesc.GetNestedEnumTypes() {
+			visitType(nested)
+		}
+	}
+
+	visitType(msgDesc)
+	return types
+}
+
+// writeImports imports the types declared by previous tables that the row message or the new types refer to
+func (te *TsExporter) writeImports(code *strings.Builder, msgDesc *desc.MessageDescriptor, newTypes []desc.Descriptor, module string) {
+	importedTypes := make(map[string][]string)
+	seen := map[string]bool{msgDesc.GetFullyQualifiedName(): true}
+	// A message used by several tables is declared by the first one
+	if rowModule := te.typeModules[msgDesc.GetFullyQualifiedName()]; rowModule != module {
+		importedTypes[rowModule] = append(importedTypes[rowModule], tsTypeName(msgDesc))
+	}
+	for _, typeDesc := range newTypes {
+		typeMsg, ok := typeDesc.(*desc.MessageDescriptor)
+		if !ok {
+			continue
+		}
+		for _, field := range typeMsg.GetFields() {
+			if field.IsMap() {
+				field = field.GetMapValueType()
+			}
+			var fieldType desc.Descriptor
+			if field.GetMessageType() != nil {
+				fieldType = field.GetMessageType()
+			} else if field.GetEnumType() != nil {
+				fieldType = field.GetEnumType()
+			}
+			if fieldType == nil || seen[fieldType.GetFullyQualifiedName()] {
+				continue
+			}
+			seen[fieldType.GetFullyQualifiedName()] = true
+			if typeModule, ok := te.typeModules[fieldType.GetFullyQualifiedName()]; ok && typeModule != module {
+				importedTypes[typeModule] = append(importedTypes[typeModule], tsTypeName(fieldType))
+			}
+		}
+	}
+
+	modules := make([]string, 0, len(importedTypes))
+	for typeModule := range importedTypes {
+		modules = append(modules, typeModule)
+	}
+	sort.Strings(modules)
+	if len(modules) > 0 {
+		code.WriteString("\n")
+	}
+	for _, typeModule := range modules {
+		names := importedTypes[typeModule]
+		sort.Strings(names)
+		code.WriteString(fmt.Sprintf("import type { %s } from %s;\n", strings.Join(names, ", "), quoteJSONString("./"+typeModule)))
+	}
+}
+
+// writeInterface writes the interface of a message as the JSON output writes it, with every field present
+func (te *TsExporter) writeInterface(code *strings.Builder, msgDesc *desc.MessageDescriptor) {
+	code.WriteString("\n")
+	writeTsDoc(code, "", []string{getDescriptorComment(msgDesc)})
+	code.WriteString(fmt.Sprintf("export interface %s {\n", tsTypeName(msgDesc)))
+	for _, field := range getSortedFields(msgDesc) {
+		writeTsDoc(code, "    ", getFieldDescription(field))
+		code.WriteString(fmt.Sprintf("    %s: %s;\n", tsPropertyName(te.jsonFieldName(field)), te.fieldType(field)))
+	}
+	code.WriteString("}\n")
+}
+
+// writeEnum writes a const enum with the numbers of the enum values
+func (te *TsExporter) writeEnum(code *strings.Builder, enumDesc *desc.EnumDescriptor) {
+	code.WriteString("\n")
+	writeTsDoc(code, "", []string{getDescriptorComment(enumDesc)})
+	code.WriteString(fmt.Sprintf("export const enum %s {\n", tsTypeName(enumDesc)))
+	for _, enumVal := range enumDesc.GetValues() {
+		var description []string
+		if displayName := getEnumDisplayName(enumVal); displayName != enumVal.GetName() {
+			description = append(description, displayName)
+		}
+		writeTsDoc(code, "    ", append(description, getDescriptorComment(enumVal)))
+		code.WriteString(fmt.Sprintf("    %s = %d,\n", enumVal.GetName(), enumVal.GetNumber()))
+	}
+	code.WriteString("}\n")
+}
+
+// writeTableType writes the type of the whole JSON output of a table, records nested along the (keys)
+// hierarchy or an array for tables without keys, and returns its name
+func (te *TsExporter) writeTableType(code *strings.Builder, store *TableStore, keyFields []*desc.FieldDescriptor) string {
+	rowType := tsTypeName(store.GetMessageDescriptor())
+	tableType := rowType + "Table"
+
+	keyNames := make([]string, len(keyFields))
+	valueType := rowType
+	for i := len(keyFields) - 1; i >= 0; i-- {
+		keyNames[i] = keyFields[i].GetName()
+		valueType = fmt.Sprintf("Record<%s, %s>", tsKeyType(keyFields[i]), valueType)
+	}
+
+	fileName := GetTableName(store) + ".json"
+	code.WriteString("\n")
+	if len(keyFields) > 0 {
+		writeTsDoc(code, "", []string{fmt.Sprintf("Rows of %s by %s", fileName, strings.Join(keyNames, ", "))})
+		code.WriteString(fmt.Sprintf("export type %s = %s;\n", tableType, valueType))
+	} else {
+		writeTsDoc(code, "", []string{fmt.Sprintf("Rows of %s in sheet order", fileName)})
+		code.WriteString(fmt.Sprintf("export type %s = %s[];\n", tableType, rowType))
+	}
+	return tableType
+}
+
+// writeDataModule writes an ES module exporting the JSON output of a table as a constant of its table type
+func (te *TsExporter) writeDataModule(store *TableStore, module, tableType string) error {
+	jsonExporter := &JsonExporter{Style: te.JsonStyle, EnumFormat: te.EnumFormat}
+	var data interface{}
+	if store.HasChildStores() {
+		var err error
+		if data, err = jsonExporter.exportStoreToInterface(store); err != nil {
+			return err
+		}
+	} else {
+		rows := make([]interface{}, 0, len(store.GetAllMessages()))
+		for _, message := range store.GetAllMessages() {
+			row, err := jsonExporter.convertMessage(message)
+			if err != nil {
+				return err
+			}
+			rows = append(rows, row)
+		}
+		data = rows
+	}
+	dataBytes, err := json.MarshalIndent(data, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %v", err)
+	}
+
+	msgDesc := store.GetMessageDescriptor()
+	var code strings.Builder
+	code.WriteString(fmt.Sprintf("// Code generated by protoxls from %s. DO NOT EDIT.\n\n", msgDesc.GetFile().GetName()))
+	code.WriteString(fmt.Sprintf("import type { %s } from %s;\n\n", tableType, quoteJSONString("./"+module)))
+	code.WriteString(fmt.Sprintf("export const %s: %s = %s;\n", strings.ToLower(tableType[:1])+tableType[1:], tableType, dataBytes))
+
+	// The declarations already use the table name, so the module gets a second extension
+	file, err := CreateNamedOutputFile(module+".data", te.OutputDir, "TypeScript")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if _, err := file.WriteString(code.String()); err != nil {
+		return fmt.Errorf("failed to write TypeScript module: %v", err)
+	}
+	return nil
+}
+
+// fieldType returns the type of a field in the JSON output, including repeated and map fields
+func (te *TsExporter) fieldType(field *desc.FieldDescriptor) string {
+	switch {
+	case field.IsMap():
+		return fmt.Sprintf("Record<%s, %s>", tsKeyType(field.GetMapKeyType()), te.valueType(field.GetMapValueType()))
+	case field.IsRepeated():
+		elementType := te.valueType(field)
+		if strings.Contains(elementType, " ") {
+			elementType = "(" + elementType + ")"
+		}
+		return elementType + "[]"
+	case field.GetMessageType() != nil:
+		// Unset message fields are written as null
+		valueType := te.valueType(field)
+		if te.JsonStyle == JsonStyleProtoJSON && te.wellKnownType(field.GetMessageType()) != "" {
+			return valueType
+		}
+		return valueType + " | null"
+	case field.GetType().String() == "TYPE_BYTES" && te.JsonStyle != JsonStyleProtoJSON:
+		// Empty bytes are written as null in the default style
+		return "string | null"
+	default:
+		return te.valueType(field)
+	}
+}
+
+// valueType maps the type of a single field value to the type of its JSON value
+func (te *TsExporter) valueType(field *desc.FieldDescriptor) string {
+	protoJSON := te.JsonStyle == JsonStyleProtoJSON
+	switch field.GetType().String() {
+	case "TYPE_MESSAGE", "TYPE_GROUP":
+		if wellKnown := te.wellKnownType(field.GetMessageType()); wellKnown != "" {
+			return wellKnown
+		}
+		return tsTypeName(field.GetMessageType())
+	case "TYPE_ENUM":
+		format := EnumFormatName
+		if !protoJSON {
+			format = getEnumFormat(field, te.EnumFormat)
+		}
+		switch format {
+		case EnumFormatName:
+			return "keyof typeof " + tsTypeName(field.GetEnumType())
+		case EnumFormatAlias:
+			// Display names are no values of the enum
+			return "string"
+		default:
+			return tsTypeName(field.GetEnumType())
+		}
+	case "TYPE_INT64", "TYPE_SINT64", "TYPE_SFIXED64", "TYPE_UINT64", "TYPE_FIXED64":
+		// 64-bit integers are quoted in protojson
+		if protoJSON {
+			return "string"
+		}
+		return "number"
+	case "TYPE_FLOAT", "TYPE_DOUBLE":
+		return tsFloatType
+	case "TYPE_BOOL":
+		return "boolean"
+	case "TYPE_STRING", "TYPE_BYTES":
+		return "string"
+	default:
+		return "number"
+	}
+}
+
+// wellKnownType returns the type of the special protojson forms of well-known types, or an empty string
+// if the message is written as a regular object
+func (te *TsExporter) wellKnownType(msgDesc *desc.MessageDescriptor) string {
+	if te.JsonStyle != JsonStyleProtoJSON {
+		return ""
+	}
+	switch msgDesc.GetFullyQualifiedName() {
+	case "google.protobuf.Timestamp", "google.protobuf.Duration", "google.protobuf.FieldMask":
+		return "string"
+	case "google.protobuf.Struct", "google.protobuf.Any":
+		return "Record<string, unknown>"
+	case "google.protobuf.ListValue":
+		return "unknown[]"
+	case "google.protobuf.Value":
+		return "unknown"
+	case "google.protobuf.BoolValue":
+		return "boolean | null"
+	case "google.protobuf.StringValue", "google.protobuf.BytesValue",
+		"google.protobuf.Int64Value", "google.protobuf.UInt64Value":
+		return "string | null"
+	case "google.protobuf.Int32Value", "google.protobuf.UInt32Value":
+		return "number | null"
+	case "google.protobuf.FloatValue", "google.protobuf.DoubleValue":
+		return tsFloatType + " | null"
+	}
+	return ""
+}
+
+// jsonFieldName returns the key of a field in the JSON output
+func (te *TsExporter) jsonFieldName(field *desc.FieldDescriptor) string {
+	if te.JsonStyle == JsonStyleProtoJSON {
+		return field.GetJSONName()
+	}
+	return field.GetName()
+}
+
+// tsTypeName returns the TypeScript name of a message or enum, nested types joined to their parents by underscores
+func tsTypeName(typeDesc desc.Descriptor) string {
+	name := typeDesc.GetFullyQualifiedName()
+	if pkg := typeDesc.GetFile().GetPackage(); pkg != "" {
+		name = strings.TrimPrefix(name, pkg+".")
+	}
+	return strings.Replace(name, ".", "_", -1)
+}
+
+// tsKeyType returns the record key type of a (keys) or map key field, integer keys are written as numeric strings
+func tsKeyType(field *desc.FieldDescriptor) string {
+	if isIntegerKeyField(field) {
+		return "number"
+	}
+	return "string"
+}
+
+// tsPropertyName returns a property name, quoted if it is no identifier
+func tsPropertyName(name string) string {
+	for i, c := range name {
+		if !(c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9') {
+			return quoteJSONString(name)
+		}
+	}
+	if name == "" {
+		return `""`
+	}
+	return name
+}
+
+// writeTsDoc writes the non-empty lines of a description as a doc comment, nothing if all are empty
+func writeTsDoc(code *strings.Builder, indent string, description []string) {
+	var lines []string
+	for _, text := range description {
+		for _, line := range strings.Split(text, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				// A comment end in the description would close the doc comment
+				lines = append(lines, strings.Replace(line, "*/", "*\\/", -1))
+			}
+		}
+	}
+	switch len(lines) {
+	case 0:
+	case 1:
+		code.WriteString(fmt.Sprintf("%s/** %s */\n", indent, lines[0]))
+	default:
+		code.WriteString(indent + "/**\n")
+		for _, line := range lines {
+			code.WriteString(fmt.Sprintf("%s * %s\n", indent, line))
+		}
+		code.WriteString(indent + " */\n")
+	}
+}
@@ -0,0 +1,231 @@
+package protoxls
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+)
+
+const (
+	// textProtoIndent is the indentation of nested messages, as written by protoc and txtpbfmt
+	textProtoIndent = "  "
+	// textProtoRowsField is the name of the field holding the rows in the wrapper messages
+	textProtoRowsField = "rows"
+)
+
+// TextProtoExporter exports configuration data in the protobuf text format (.txtpb)
+type TextProtoExporter struct {
+	OutputDir string // Custom output directory, defaults to DefaultOutputDir if empty
+}
+
+// ExportResult exports the table as the text format of a wrapper message. Tables without (keys) are a
+// repeated field of rows in sheet order, keyed tables a map per (keys) field like the JSON output:
+//
+//	message HeroConfigList { repeated HeroConfig rows = 1; }
+//	message HeroConfigMap { map<int32, HeroConfig> rows = 1; }
+func (te *TextProtoExporter) ExportResult(store *TableStore) error {
+	var keyFields []*desc.FieldDescriptor
+	if store.HasChildStores() {
+		var err error
+		if keyFields, err = getTableKeyFields(store); err != nil {
+			return err
+		}
+	}
+
+	file, err := CreateOutputFile(store, te.OutputDir, "txtpb")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	writer.WriteString(textProtoHeader(store, keyFields))
+	if len(keyFields) == 0 {
+		for _, message := range store.GetAllMessages() {
+			writer.WriteString(textProtoRowsField + " {\n")
+			writer.WriteString(formatTextMessage(message, 1))
+			writer.WriteString("}\n")
+		}
+	} else {
+		writer.WriteString(formatTextKeyedStore(store, keyFields, 0))
+	}
+
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write text proto: %v", err)
+	}
+	return nil
+}
+
+// textProtoHeader describes the wrapper messages of the table in comments, as they are not part of the schema
+func textProtoHeader(store *TableStore, keyFields []*desc.FieldDescriptor) string {
+	msgDesc := store.GetMessageDescriptor()
+	name := msgDesc.GetName()
+
+	var header strings.Builder
+	if len(keyFields) == 0 {
+		header.WriteString(fmt.Sprintf("# Rows of %s in the text format of:\n", msgDesc.GetFullyQualifiedName()))
+		header.WriteString(fmt.Sprintf("#   message %sList { repeated %s %s = 1; }\n", name, name, textProtoRowsField))
+		return header.String()
+	}
+
+	keyNames := make([]string, len(keyFields))
+	for i, keyField := range keyFields {
+		keyNames[i] = keyField.GetName()
+	}
+	header.WriteString(fmt.Sprintf("# Rows of %s keyed by %s in the text format of:\n", msgDesc.GetFullyQualifiedName(), strings.Join(keyNames, ", ")))
+	for i, keyField := range keyFields {
+		valueType := name
+		if i < len(keyFields)-1 {
+			valueType = textProtoMapName(name, i+1)
+		}
+		keyType := strings.ToLower(strings.TrimPrefix(keyField.GetType().String(), "TYPE_"))
+		header.WriteString(fmt.Sprintf("#   message %s { map<%s, %s> %s = 1; }\n", textProtoMapName(name, i), keyType, valueType, textProtoRowsField))
+	}
+	return header.String()
+}
+
+// textProtoMapName returns the name of the wrapper message of a (keys) level
+func textProtoMapName(name string, level int) string {
+	if level == 0 {
+		return name + "Map"
+	}
+	return fmt.Sprintf("%sMap%d", name, level+1)
+}
+
+// formatTextKeyedStore formats a (keys) level as map entries in key order, holding the first row of each key.
+// keyFields holds the (keys) fields of this level and the levels below it
+func formatTextKeyedStore(store *TableStore, keyFields []*desc.FieldDescriptor, depth int) string {
+	indent := strings.Repeat(textProtoIndent, depth)
+	var result strings.Builder
+	for _, key := range store.GetAllKeys() {
+		childStore := store.GetChildStore(key)
+		if childStore == nil {
+			continue
+		}
+
+		var value string
+		if childStore.HasChildStores() {
+			value = formatTextKeyedStore(childStore, keyFields[1:], depth+2)
+		} else if message := childStore.GetFirstMessage(); message != nil {
+			value = formatTextMessage(message, depth+2)
+		} else {
+			continue
+		}
+
+		keyLiteral := key.String()
+		if keyFields[0].GetType().String() == "TYPE_STRING" {
+			keyLiteral = quoteTextString(keyLiteral)
+		}
+		result.WriteString(indent + textProtoRowsField + " {\n")
+		result.WriteString(indent + textProtoIndent + "key: " + keyLiteral + "\n")
+		result.WriteString(indent + textProtoIndent + "value {\n")
+		result.WriteString(value)
+		result.WriteString(indent + textProtoIndent + "}\n")
+		result.WriteString(indent + "}\n")
+	}
+	return result.String()
+}
+
+// formatTextMessage formats the set fields of a message in field number order, one per line
+func formatTextMessage(msg *dynamic.Message, depth int) string {
+	fields := getSortedFields(msg.GetMessageDescriptor())
+
+	var result strings.Builder
+	for _, field := range fields {
+		if !msg.HasField(field) {
+			continue
+		}
+		value := msg.GetField(field)
+		name := field.GetName()
+		if field.GetType().String() == "TYPE_GROUP" {
+			name = field.GetMessageType().GetName()
+		}
+
+		switch {
+		case field.IsMap():
+			result.WriteString(formatTextMap(name, value, field, depth))
+		case field.IsRepeated():
+			items, _ := value.([]interface{})
+			for _, item := range items {
+				result.WriteString(formatTextField(name, item, field, depth))
+			}
+		default:
+			result.WriteString(formatTextField(name, value, field, depth))
+		}
+	}
+	return result.String()
+}
+
+// formatTextMap formats a map field as one entry message per key, sorted by key as protoc does
+func formatTextMap(name string, value interface{}, field *desc.FieldDescriptor, depth int) string {
+	entries, _ := value.(map[interface{}]interface{})
+	keys := make([]interface{}, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return lessMapKey(keys[i], keys[j])
+	})
+
+	indent := strings.Repeat(textProtoIndent, depth)
+	var result strings.Builder
+	for _, key := range keys {
+		result.WriteString(indent + name + " {\n")
+		result.WriteString(formatTextField("key", key, field.GetMapKeyType(), depth+1))
+		result.WriteString(formatTextField("value", entries[key], field.GetMapValueType(), depth+1))
+		result.WriteString(indent + "}\n")
+	}
+	return result.String()
+}
+
+// lessMapKey orders map keys of the same type: bools false first, integers by value, strings bytewise
+func lessMapKey(a, b interface{}) bool {
+	switch av := a.(type) {
+	case bool:
+		return !av && b.(bool)
+	case int32:
+		return av < b.(int32)
+	case int64:
+		return av < b.(int64)
+	case uint32:
+		return av < b.(uint32)
+	case uint64:
+		return av < b.(uint64)
+	case string:
+		return av < b.(string)
+	}
+	return false
+}
+
+// formatTextField formats one value of a field as a line, or a block for messages
+func formatTextField(name string, value interface{}, field *desc.FieldDescriptor, depth int) string {
+	indent := strings.Repeat(textProtoIndent, depth)
+	if msg, ok := value.(*dynamic.Message); ok {
+		return indent + name + " {\n" + formatTextMessage(msg, depth+1) + indent + "}\n"
+	}
+	return indent + name + ": " + formatTextScalar(value, field) + "\n"
+}
+
+// formatTextScalar formats a scalar value as a text format literal
+func formatTextScalar(value interface{}, field *desc.FieldDescriptor) string {
+	switch v := value.(type) {
+	case string:
+		return quoteTextString(v)
+	case []byte:
+		return quoteTextBytes(v)
+	case float32, float64:
+		return textFloatLiterals.format(v)
+	case int32:
+		if enumDesc := field.GetEnumType(); enumDesc != nil {
+			// Unknown numbers have no name and are written as numbers, which the text format accepts
+			if enumVal := enumDesc.FindValueByNumber(v); enumVal != nil {
+				return enumVal.GetName()
+			}
+		}
+	}
+	return fmt.Sprintf("%v", value)
+}
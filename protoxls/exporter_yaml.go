@@ -2,7 +2,8 @@ package protoxls
 
 import (
 	"fmt"
-	"sort"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/jhump/protoreflect/desc"
 	"github.com/jhump/protoreflect/dynamic"
@@ -11,7 +12,8 @@ import (
 
 // YamlExporter exports data to YAML format
 type YamlExporter struct {
-	OutputDir string
+	OutputDir  string
+	EnumFormat string // How enum fields are exported, EnumFormatNumber if empty
 }
 
 // ExportResult exports the table store data to a YAML file
@@ -47,7 +49,7 @@ func (e *YamlExporter) ExportResult(store *TableStore) error {
 // exportStoreToInterface converts TableStore to interface{} for YAML export
 func (e *YamlExporter) exportStoreToInterface(store *TableStore) (interface{}, error) {
 	if store.HasChildStores() {
-		result := make(map[string]interface{})
+		result := make(map[yamlKey]interface{})
 		keys := store.GetAllKeys()
 		for _, key := range keys {
 			childStore := store.GetChildStore(key)
@@ -56,7 +58,7 @@ func (e *YamlExporter) exportStoreToInterface(store *TableStore) (interface{}, e
 				if err != nil {
 					return nil, err
 				}
-				result[key.String()] = childData
+				result[yamlKey(key.String())] = childData
 			}
 		}
 		return result, nil
@@ -83,12 +85,8 @@ func (e *YamlExporter) convertMessageToMap(msg *dynamic.Message) *yaml.Node {
 	}
 	
 	descriptor := msg.GetMessageDescriptor()
-	fields := descriptor.GetFields()
-
 	// Sort fields by field number to maintain proto definition order (consistent with JSON and PHP exporters)
-	sort.Slice(fields, func(i, j int) bool {
-		return fields[i].GetNumber() < fields[j].GetNumber()
-	})
+	fields := getSortedFields(descriptor)
 
 	for _, field := range fields {
 		value := msg.GetField(field)
@@ -116,6 +114,24 @@ func (e *YamlExporter) convertMessageToMap(msg *dynamic.Message) *yaml.Node {
 	return result
 }
 
+// yamlKey is a table key, written like string values and sorted like any other string key
+type yamlKey string
+
+// MarshalYAML implements yaml.Marshaler
+func (k yamlKey) MarshalYAML() (interface{}, error) {
+	return yamlString(string(k)), nil
+}
+
+// yamlString returns a string for YAML serialization. A leading line break is lost in the literal block
+// style chosen by yaml.v3, so such strings are double-quoted instead. Invalid UTF-8 is left to yaml.v3,
+// which writes it as !!binary
+func yamlString(s string) interface{} {
+	if utf8.ValidString(s) && strings.HasPrefix(s, "\n") {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s, Style: yaml.DoubleQuotedStyle}
+	}
+	return s
+}
+
 // convertValueToYamlNode converts a value to yaml.Node
 func (e *YamlExporter) convertValueToYamlNode(value interface{}) *yaml.Node {
 	node := &yaml.Node{}
@@ -136,8 +152,15 @@ func (e *YamlExporter) convertSingleFieldValue(value interface{}, field *desc.Fi
 			return e.convertMessageToOrderedMap(dmsg)
 		}
 	case "TYPE_ENUM":
-		// Return enum as number
-		return value
+		if number, ok := value.(int32); ok {
+			return convertEnumValue(number, field, e.EnumFormat)
+		}
+	case "TYPE_FLOAT", "TYPE_DOUBLE":
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: yamlFloatLiterals.format(value)}
+	case "TYPE_STRING":
+		if s, ok := value.(string); ok {
+			return yamlString(s)
+		}
 	default:
 		return value
 	}
@@ -152,12 +175,8 @@ func (e *YamlExporter) convertMessageToOrderedMap(msg *dynamic.Message) *yaml.No
 	}
 	
 	descriptor := msg.GetMessageDescriptor()
-	fields := descriptor.GetFields()
-
 	// Sort fields by field number to maintain proto definition order
-	sort.Slice(fields, func(i, j int) bool {
-		return fields[i].GetNumber() < fields[j].GetNumber()
-	})
+	fields := getSortedFields(descriptor)
 
 	for _, field := range fields {
 		value := msg.GetField(field)
@@ -198,8 +217,15 @@ func (e *YamlExporter) convertSingleFieldValueForNested(value interface{}, field
 			return e.convertMessageToOrderedMap(dmsg)
 		}
 	case "TYPE_ENUM":
-		// Return enum as number
-		return value
+		if number, ok := value.(int32); ok {
+			return convertEnumValue(number, field, e.EnumFormat)
+		}
+	case "TYPE_FLOAT", "TYPE_DOUBLE":
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: yamlFloatLiterals.format(value)}
+	case "TYPE_STRING":
+		if s, ok := value.(string); ok {
+			return yamlString(s)
+		}
 	default:
 		return value
 	}
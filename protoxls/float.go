@@ -0,0 +1,92 @@
+package protoxls
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// floatLiterals spells the non-finite float values in a target language
+type floatLiterals struct {
+	NaN    string
+	PosInf string
+	NegInf string
+}
+
+var (
+	luaFloatLiterals  = floatLiterals{NaN: "0/0", PosInf: "math.huge", NegInf: "-math.huge"}
+	phpFloatLiterals  = floatLiterals{NaN: "NAN", PosInf: "INF", NegInf: "-INF"}
+	yamlFloatLiterals = floatLiterals{NaN: ".nan", PosInf: ".inf", NegInf: "-.inf"}
+	textFloatLiterals = floatLiterals{NaN: "nan", PosInf: "inf", NegInf: "-inf"}
+	// Python modules using these literals import math
+	pythonFloatLiterals = floatLiterals{NaN: "math.nan", PosInf: "math.inf", NegInf: "-math.inf"}
+	// JSON has no non-finite numbers, they are written as the strings used by protojson
+	jsonFloatLiterals = floatLiterals{NaN: "NaN", PosInf: "Infinity", NegInf: "-Infinity"}
+)
+
+// format formats a float32 or float64 field value, spelling NaN and infinities with the literals
+func (fl floatLiterals) format(value interface{}) string {
+	f, bitSize := floatValue(value)
+	switch {
+	case math.IsNaN(f):
+		return fl.NaN
+	case math.IsInf(f, 1):
+		return fl.PosInf
+	case math.IsInf(f, -1):
+		return fl.NegInf
+	}
+	return formatFloat(f, bitSize)
+}
+
+// floatValue returns a float32 or float64 field value as float64 with the bit size of its type
+func floatValue(value interface{}) (float64, int) {
+	switch v := value.(type) {
+	case float32:
+		return float64(v), 32
+	case float64:
+		return v, 64
+	}
+	return 0, 64
+}
+
+// formatFloat formats a finite float with the fewest digits that read back as the same value at its
+// bit size, so float32 values are not widened to float64 noise. Like encoding/json, exponents are only
+// used below 1e-6 and from 1e21 on. The result always has a fraction or exponent so that languages
+// with separate integer and float types read it back as a float
+func formatFloat(f float64, bitSize int) string {
+	abs := math.Abs(f)
+	format := byte('f')
+	if abs != 0 {
+		if bitSize == 64 && (abs < 1e-6 || abs >= 1e21) ||
+			bitSize == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+
+	s := strconv.FormatFloat(f, format, -1, bitSize)
+	if format == 'e' {
+		mantissa, exponent := s[:strings.IndexByte(s, 'e')], s[strings.IndexByte(s, 'e'):]
+		// Shorten e-07 to e-7
+		if len(exponent) == 4 && exponent[2] == '0' {
+			exponent = exponent[:2] + exponent[3:]
+		}
+		// YAML 1.1 only reads exponents with a fraction as floats
+		if !strings.Contains(mantissa, ".") {
+			mantissa += ".0"
+		}
+		return mantissa + exponent
+	}
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s
+}
+
+// convertJSONFloat converts a float field value into a value encoding/json writes with formatFloat
+func convertJSONFloat(value interface{}) interface{} {
+	if f, _ := floatValue(value); math.IsNaN(f) || math.IsInf(f, 0) {
+		return jsonFloatLiterals.format(value)
+	}
+	return json.Number(jsonFloatLiterals.format(value))
+}
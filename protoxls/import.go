@@ -0,0 +1,666 @@
+package protoxls
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+	"github.com/xuri/excelize/v2"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+)
+
+// ImportConfig holds configuration for importing exported data back into Excel
+type ImportConfig struct {
+	InputPath   string // Exported JSON or YAML file to import
+	Format      string // Input format ("json" or "yaml"), inferred from the file extension if empty
+	MessageName string // Table message of the input, inferred from the file name if empty
+	ArraySlots  int    // Number of indexed column groups when a new workbook has to be created
+	Replace     bool   // Whether to replace all data rows instead of updating rows by primary key
+}
+
+// ImportDataFiles parses proto files and writes the rows of an exported file into the table workbook
+func ImportDataFiles(protoFile string, importPaths []string, config *ImportConfig) error {
+	fileDescriptors, err := LoadProtoFiles(protoFile, importPaths)
+	if err != nil {
+		return err
+	}
+
+	msgDesc, err := findImportMessage(FindExcelMessages(fileDescriptors), config)
+	if err != nil {
+		return err
+	}
+
+	messages, err := readExportedMessages(msgDesc, config)
+	if err != nil {
+		return err
+	}
+	return ImportMessages(msgDesc, messages, config)
+}
+
+// findImportMessage finds the table message of the input by name or by the exported file name
+func findImportMessage(messages []*desc.MessageDescriptor, config *ImportConfig) (*desc.MessageDescriptor, error) {
+	baseName := strings.TrimSuffix(filepath.Base(config.InputPath), filepath.Ext(config.InputPath))
+	for _, md := range messages {
+		if config.MessageName != "" {
+			if md.GetName() == config.MessageName || md.GetFullyQualifiedName() == config.MessageName {
+				return md, nil
+			}
+		} else if GetTableName(NewTableStore(md)) == baseName {
+			return md, nil
+		}
+	}
+
+	if config.MessageName != "" {
+		return nil, fmt.Errorf("message %s not found or missing excel option", config.MessageName)
+	}
+	return nil, fmt.Errorf("no table named %s found, use -message to select the message", baseName)
+}
+
+// readExportedMessages reads an exported JSON or YAML file and decodes its rows against the descriptor
+func readExportedMessages(msgDesc *desc.MessageDescriptor, config *ImportConfig) ([]*dynamic.Message, error) {
+	content, err := os.ReadFile(config.InputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", config.InputPath, err)
+	}
+
+	format := strings.ToLower(config.Format)
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(config.InputPath)), ".")
+	}
+
+	var data interface{}
+	switch format {
+	case "json":
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber()
+		if err := decoder.Decode(&data); err != nil {
+			return nil, fmt.Errorf("failed to decode JSON %s: %v", config.InputPath, err)
+		}
+	case "yaml", "yml":
+		if err := yaml.Unmarshal(content, &data); err != nil {
+			return nil, fmt.Errorf("failed to decode YAML %s: %v", config.InputPath, err)
+		}
+		data = normalizeYamlData(data)
+	default:
+		return nil, fmt.Errorf("unsupported import format: %s", format)
+	}
+
+	// Keyed tables are nested one object level per key field
+	depth := len(getKeyFieldNames(msgDesc))
+	var rows []interface{}
+	if err := collectExportedRows(data, depth, &rows); err != nil {
+		return nil, err
+	}
+
+	messages := make([]*dynamic.Message, 0, len(rows))
+	for i, row := range rows {
+		message, err := decodeMessageData(msgDesc, row)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode row %d: %v", i+1, err)
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+// normalizeYamlData converts YAML maps with non-string keys into string keyed maps
+func normalizeYamlData(data interface{}) interface{} {
+	switch v := data.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			result[fmt.Sprint(key)] = normalizeYamlData(value)
+		}
+		return result
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = normalizeYamlData(value)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeYamlData(item)
+		}
+		return v
+	default:
+		return data
+	}
+}
+
+// getKeyFieldNames returns the key field names configured by the keys option
+func getKeyFieldNames(msgDesc *desc.MessageDescriptor) []string {
+	options := msgDesc.GetMessageOptions()
+	if options == nil {
+		return nil
+	}
+	keys, ok := proto.GetExtension(options, E_Keys).(string)
+	if !ok || keys == "" {
+		return nil
+	}
+	return strings.Split(keys, ";")
+}
+
+// collectExportedRows flattens arrays and keyed objects of an exported file into message rows
+func collectExportedRows(data interface{}, depth int, rows *[]interface{}) error {
+	switch v := data.(type) {
+	case []interface{}:
+		// Array exports contain one message per element
+		*rows = append(*rows, v...)
+		return nil
+	case map[string]interface{}:
+		if depth == 0 {
+			*rows = append(*rows, v)
+			return nil
+		}
+		for _, key := range sortedKeys(v) {
+			if err := collectExportedRows(v[key], depth-1, rows); err != nil {
+				return err
+			}
+		}
+		return nil
+	case nil:
+		return nil
+	default:
+		return fmt.Errorf("unexpected %T in exported data", data)
+	}
+}
+
+// sortedKeys returns map keys in numeric order when they are all integers, otherwise in lexical order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	numeric := true
+	for key := range m {
+		keys = append(keys, key)
+		if _, err := strconv.ParseInt(key, 10, 64); err != nil {
+			numeric = false
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if numeric {
+			a, _ := strconv.ParseInt(keys[i], 10, 64)
+			b, _ := strconv.ParseInt(keys[j], 10, 64)
+			return a < b
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// decodeMessageData decodes a decoded JSON or YAML object into a dynamic message
+func decodeMessageData(msgDesc *desc.MessageDescriptor, data interface{}) (*dynamic.Message, error) {
+	object, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected object for message %s, got %T", msgDesc.GetName(), data)
+	}
+
+	message := dynamic.NewMessage(msgDesc)
+	for name, value := range object {
+		field := msgDesc.FindFieldByName(name)
+		if field == nil {
+			field = msgDesc.FindFieldByJSONName(name)
+		}
+		if field == nil {
+			return nil, fmt.Errorf("unknown field %s in message %s", name, msgDesc.GetName())
+		}
+		if value == nil || field.IsMap() {
+			continue
+		}
+
+		if field.IsRepeated() {
+			items, ok := value.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("expected array for field %s, got %T", name, value)
+			}
+			for _, item := range items {
+				fieldValue, err := decodeFieldValue(field, item)
+				if err != nil {
+					return nil, err
+				}
+				message.AddRepeatedField(field, fieldValue)
+			}
+			continue
+		}
+
+		fieldValue, err := decodeFieldValue(field, value)
+		if err != nil {
+			return nil, err
+		}
+		message.SetField(field, fieldValue)
+	}
+	return message, nil
+}
+
+// decodeFieldValue converts a decoded JSON or YAML value into the Go type of a field
+func decodeFieldValue(field *desc.FieldDescriptor, value interface{}) (interface{}, error) {
+	switch field.GetType().String() {
+	case "TYPE_MESSAGE":
+		return decodeMessageData(field.GetMessageType(), value)
+	case "TYPE_ENUM":
+		if name, ok := value.(string); ok {
+			if number, err := strconv.ParseInt(name, 10, 32); err == nil {
+				return int32(number), nil
+			}
+			return parseEnumValue(name, field)
+		}
+	case "TYPE_STRING":
+		if str, ok := value.(string); ok {
+			return str, nil
+		}
+		return nil, fmt.Errorf("expected string for field %s, got %T", field.GetName(), value)
+	case "TYPE_BOOL":
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+		return nil, fmt.Errorf("expected bool for field %s, got %T", field.GetName(), value)
+	}
+
+	// Numbers may be encoded as JSON numbers, YAML scalars or strings for 64-bit integers
+	text := ""
+	switch v := value.(type) {
+	case json.Number:
+		text = v.String()
+	case string:
+		text = v
+	case int:
+		text = strconv.Itoa(v)
+	case int64:
+		text = strconv.FormatInt(v, 10)
+	case uint64:
+		text = strconv.FormatUint(v, 10)
+	case float64:
+		text = strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		return nil, fmt.Errorf("expected number for field %s, got %T", field.GetName(), value)
+	}
+
+	switch field.GetType().String() {
+	case "TYPE_ENUM":
+		number, err := strconv.ParseInt(text, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid enum value %s for field %s", text, field.GetName())
+		}
+		return int32(number), nil
+	case "TYPE_UINT32", "TYPE_FIXED32":
+		// Dynamic messages hold unsigned fields as unsigned integers
+		number, err := strconv.ParseUint(text, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid uint32 value %s for field %s", text, field.GetName())
+		}
+		return uint32(number), nil
+	case "TYPE_UINT64", "TYPE_FIXED64":
+		number, err := strconv.ParseUint(text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid uint64 value %s for field %s", text, field.GetName())
+		}
+		return number, nil
+	}
+
+	// Non-finite floats such as "NaN" or "Infinity" are accepted by strconv.ParseFloat
+	return convertCellValue(text, field)
+}
+
+// ImportMessages writes messages into the configured sheet, updating rows in place by primary key
+func ImportMessages(msgDesc *desc.MessageDescriptor, messages []*dynamic.Message, config *ImportConfig) error {
+	excelPath, sheetName, err := getExcelOptions(msgDesc)
+	if err != nil {
+		return err
+	}
+
+	// Start from a template when the workbook does not exist yet
+	if _, err := os.Stat(excelPath); os.IsNotExist(err) {
+		templateConfig := &TemplateConfig{ArraySlots: config.ArraySlots}
+		if err := GenerateTemplate([]*desc.MessageDescriptor{msgDesc}, excelPath, templateConfig); err != nil {
+			return err
+		}
+	}
+
+	excelFile, err := excelize.OpenFile(excelPath)
+	if err != nil {
+		return fmt.Errorf("failed to open excel file %s: %v", excelPath, err)
+	}
+	defer excelFile.Close()
+
+	rows, err := excelFile.GetRows(sheetName)
+	if err != nil {
+		return fmt.Errorf("failed to get sheet %s: %v", sheetName, err)
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("sheet %s has no header row, run protoxls sync first", sheetName)
+	}
+
+	// Build header map
+	headers := rows[0]
+	headerMap := make(map[string]int)
+	for index, header := range headers {
+		headerMap[header] = index
+	}
+
+	// Only columns belonging to the schema are cleared, other columns such as notes are kept
+	var schemaCols []int
+	for index, header := range headers {
+		if resolveColumnPath(msgDesc, header) != "" {
+			schemaCols = append(schemaCols, index)
+		}
+	}
+
+	keyFields, err := findKeyFields(msgDesc)
+	if err != nil {
+		return err
+	}
+
+	// Index existing rows by primary key, replaced rows are overwritten from the top
+	lastRow := len(rows)
+	nextRow := lastRow + 1
+	keyRows := make(map[string]int)
+	if config.Replace {
+		nextRow = 2
+	} else if len(keyFields) > 0 {
+		for rowIndex, row := range rows[1:] {
+			key, ok := buildRowKey(row, headerMap, keyFields)
+			if ok {
+				keyRows[key] = rowIndex + 2
+			}
+		}
+	}
+
+	updated, appended := 0, 0
+	for i, message := range messages {
+		cells := make(map[int]interface{})
+		if err := buildMessageCells(message, msgDesc, headerMap, "", cells); err != nil {
+			return fmt.Errorf("failed to write message %d: %v", i+1, err)
+		}
+
+		rowNumber := nextRow
+		if key, ok := buildMessageKey(message, keyFields); ok && len(keyFields) > 0 {
+			if existingRow, exists := keyRows[key]; exists {
+				rowNumber = existingRow
+				if err := clearRowCells(excelFile, sheetName, rowNumber, schemaCols); err != nil {
+					return err
+				}
+				updated++
+			} else {
+				keyRows[key] = rowNumber
+			}
+		}
+		if rowNumber == nextRow {
+			if rowNumber <= lastRow {
+				if err := clearRowCells(excelFile, sheetName, rowNumber, schemaCols); err != nil {
+					return err
+				}
+			}
+			nextRow++
+			appended++
+		}
+
+		for colIndex, value := range cells {
+			cell, err := excelize.CoordinatesToCellName(colIndex+1, rowNumber)
+			if err != nil {
+				return err
+			}
+			if err := excelFile.SetCellValue(sheetName, cell, value); err != nil {
+				return fmt.Errorf("failed to write cell %s: %v", cell, err)
+			}
+		}
+	}
+
+	// Remove the replaced rows past the new data, including their note columns, so they are not parsed as empty rows
+	if config.Replace {
+		for rowNumber := lastRow; rowNumber >= nextRow; rowNumber-- {
+			if err := excelFile.RemoveRow(sheetName, rowNumber); err != nil {
+				return fmt.Errorf("failed to remove row %d: %v", rowNumber, err)
+			}
+		}
+	}
+
+	if err := excelFile.Save(); err != nil {
+		return fmt.Errorf("failed to save excel file %s: %v", excelPath, err)
+	}
+
+	fmt.Printf("Imported %s into %s (%d updated, %d appended)\n", config.InputPath, excelPath, updated, appended)
+	return nil
+}
+
+// findKeyFields returns the descriptors of the key fields configured by the keys option
+func findKeyFields(msgDesc *desc.MessageDescriptor) ([]*desc.FieldDescriptor, error) {
+	var keyFields []*desc.FieldDescriptor
+	for _, name := range getKeyFieldNames(msgDesc) {
+		field := msgDesc.FindFieldByName(name)
+		if field == nil {
+			return nil, fmt.Errorf("key field %s not found in message %s", name, msgDesc.GetName())
+		}
+		keyFields = append(keyFields, field)
+	}
+	return keyFields, nil
+}
+
+// buildRowKey builds the primary key of an existing sheet row from its key columns
+func buildRowKey(row []string, headerMap map[string]int, keyFields []*desc.FieldDescriptor) (string, bool) {
+	parts := make([]string, 0, len(keyFields))
+	for _, field := range keyFields {
+		colIndex, ok := headerMap[buildFieldColumnName(field, "")]
+		if !ok || colIndex >= len(row) || row[colIndex] == "" {
+			return "", false
+		}
+
+		// Normalize the cell through the field type so that "1" and "1.0" or aliases match
+		value, err := convertCellValue(row[colIndex], field)
+		if err != nil {
+			return "", false
+		}
+		parts = append(parts, fmt.Sprint(value))
+	}
+	return strings.Join(parts, "\x00"), true
+}
+
+// buildMessageKey builds the primary key of a message from its key fields
+func buildMessageKey(message *dynamic.Message, keyFields []*desc.FieldDescriptor) (string, bool) {
+	parts := make([]string, 0, len(keyFields))
+	for _, field := range keyFields {
+		parts = append(parts, fmt.Sprint(message.GetField(field)))
+	}
+	return strings.Join(parts, "\x00"), true
+}
+
+// clearRowCells empties the given columns of a row
+func clearRowCells(excelFile *excelize.File, sheetName string, rowNumber int, cols []int) error {
+	for _, colIndex := range cols {
+		cell, err := excelize.CoordinatesToCellName(colIndex+1, rowNumber)
+		if err != nil {
+			return err
+		}
+		if err := excelFile.SetCellValue(sheetName, cell, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildMessageCells computes the cell values of a message using the column layout read by parseMessage
+func buildMessageCells(message *dynamic.Message, msgDesc *desc.MessageDescriptor, headerMap map[string]int, basePrefix string, cells map[int]interface{}) error {
+	for _, field := range msgDesc.GetFields() {
+		if field.IsMap() {
+			continue
+		}
+
+		columnName := buildFieldColumnName(field, basePrefix)
+		value := message.GetField(field)
+
+		if field.IsRepeated() {
+			items, _ := value.([]interface{})
+			if err := buildRepeatedCells(items, field, headerMap, columnName, cells); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if field.GetType().String() == "TYPE_MESSAGE" {
+			if hasCellConverter(field) && columnExists(headerMap, columnName) {
+				return fmt.Errorf("column %s uses a cell converter and cannot be written back", columnName)
+			}
+			nestedMessage, ok := value.(*dynamic.Message)
+			if !ok {
+				continue
+			}
+			if err := buildMessageCells(nestedMessage, field.GetMessageType(), headerMap, columnName, cells); err != nil {
+				return err
+			}
+			continue
+		}
+
+		colIndex, ok := headerMap[columnName]
+		if !ok {
+			if isDefaultFieldValue(value) {
+				continue
+			}
+			return fmt.Errorf("column not found: %s, run protoxls sync to add it", columnName)
+		}
+		cells[colIndex] = formatCellValue(value, field)
+	}
+	return nil
+}
+
+// buildRepeatedCells computes the cell values of a repeated field as a delimited or indexed array
+func buildRepeatedCells(items []interface{}, field *desc.FieldDescriptor, headerMap map[string]int, columnName string, cells map[int]interface{}) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	isMessage := field.GetType().String() == "TYPE_MESSAGE"
+
+	// Separator-delimited array in a single column
+	if colIndex, ok := headerMap[columnName]; ok {
+		if isMessage {
+			return fmt.Errorf("column %s uses a cell converter and cannot be written back", columnName)
+		}
+		parts := make([]string, len(items))
+		for i, item := range items {
+			parts[i] = formatCellText(item, field)
+		}
+		cells[colIndex] = strings.Join(parts, DefaultArraySeparator)
+		return nil
+	}
+
+	// Indexed columns: name[1], name[2], etc.
+	for i, item := range items {
+		elementColumnName := buildArrayElementColumnName(columnName, i+1)
+		if isMessage {
+			nestedMessage, ok := item.(*dynamic.Message)
+			if !ok {
+				continue
+			}
+			if !hasAnySubColumn(field.GetMessageType(), headerMap, elementColumnName) {
+				return fmt.Errorf("not enough indexed columns for %s: need %d, run protoxls sync with -slots", columnName, len(items))
+			}
+			if err := buildMessageCells(nestedMessage, field.GetMessageType(), headerMap, elementColumnName, cells); err != nil {
+				return err
+			}
+			continue
+		}
+
+		colIndex, ok := headerMap[elementColumnName]
+		if !ok {
+			return fmt.Errorf("column not found: %s", elementColumnName)
+		}
+		cells[colIndex] = formatCellValue(item, field)
+	}
+	return nil
+}
+
+// hasAnySubColumn checks if any field column of a nested message exists under a prefix
+func hasAnySubColumn(msgDesc *desc.MessageDescriptor, headerMap map[string]int, basePrefix string) bool {
+	for _, subField := range msgDesc.GetFields() {
+		if columnExists(headerMap, buildFieldColumnName(subField, basePrefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDefaultFieldValue checks if a scalar field value equals its zero value
+func isDefaultFieldValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case int32:
+		return v == 0
+	case int64:
+		return v == 0
+	case uint32:
+		return v == 0
+	case uint64:
+		return v == 0
+	case float32:
+		return v == 0
+	case float64:
+		return v == 0
+	}
+	return false
+}
+
+// formatCellValue converts a field value into the value written to an Excel cell
+func formatCellValue(value interface{}, field *desc.FieldDescriptor) interface{} {
+	switch field.GetType().String() {
+	case "TYPE_ENUM", "TYPE_STRING":
+		return formatCellText(value, field)
+	case "TYPE_INT64", "TYPE_SINT64", "TYPE_SFIXED64", "TYPE_UINT64", "TYPE_FIXED64":
+		// Excel numbers are doubles, which cannot hold every 64-bit integer
+		return formatCellText(value, field)
+	case "TYPE_FLOAT", "TYPE_DOUBLE":
+		// Non-finite numbers are not representable as Excel numbers
+		if f, ok := value.(float64); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+			return formatCellText(value, field)
+		}
+		if f, ok := value.(float32); ok && (math.IsNaN(float64(f)) || math.IsInf(float64(f), 0)) {
+			return formatCellText(value, field)
+		}
+	}
+	return value
+}
+
+// formatCellText converts a field value into the text accepted by convertCellValue
+func formatCellText(value interface{}, field *desc.FieldDescriptor) string {
+	switch v := value.(type) {
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case int32:
+		if field.GetType().String() == "TYPE_ENUM" {
+			return formatEnumCellText(v, field)
+		}
+		return strconv.FormatInt(int64(v), 10)
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	}
+	return fmt.Sprint(value)
+}
+
+// formatEnumCellText returns the alias of an enum value, or its name if no alias is set
+func formatEnumCellText(number int32, field *desc.FieldDescriptor) string {
+	enumVal := field.GetEnumType().FindValueByNumber(number)
+	if enumVal == nil {
+		return strconv.FormatInt(int64(number), 10)
+	}
+	if opts := enumVal.GetEnumValueOptions(); opts != nil {
+		if ext, ok := proto.GetExtension(opts, E_Alias).(string); ok && ext != "" {
+			return ext
+		}
+	}
+	return enumVal.GetName()
+}
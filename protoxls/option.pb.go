@@ -61,6 +61,14 @@ var file_option_proto_extTypes = []protoimpl.ExtensionInfo{
 		Tag:           "bytes,1001,opt,name=text",
 		Filename:      "option.proto",
 	},
+	{
+		ExtendedType:  (*descriptor.FieldOptions)(nil),
+		ExtensionType: (*string)(nil),
+		Field:         1002,
+		Name:          "enum_format",
+		Tag:           "bytes,1002,opt,name=enum_format",
+		Filename:      "option.proto",
+	},
 	{
 		ExtendedType:  (*descriptor.EnumValueOptions)(nil),
 		ExtensionType: (*string)(nil),
@@ -87,12 +95,14 @@ var (
 var (
 	// optional string text = 1001;
 	E_Text = &file_option_proto_extTypes[4]
+	// optional string enum_format = 1002;
+	E_EnumFormat = &file_option_proto_extTypes[5]
 )
 
 // Extension fields to descriptor.EnumValueOptions.
 var (
 	// optional string alias = 1001;
-	E_Alias = &file_option_proto_extTypes[5]
+	E_Alias = &file_option_proto_extTypes[6]
 )
 
 var File_option_proto protoreflect.FileDescriptor
@@ -118,12 +128,16 @@ var file_option_proto_rawDesc = []byte{
 	0x0a, 0x04, 0x74, 0x65, 0x78, 0x74, 0x12, 0x1d, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
 	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70,
 	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0xe9, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x65,
-	0x78, 0x74, 0x3a, 0x38, 0x0a, 0x05, 0x61, 0x6c, 0x69, 0x61, 0x73, 0x12, 0x21, 0x2e, 0x67, 0x6f,
-	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6e,
-	0x75, 0x6d, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0xe9,
-	0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x61, 0x6c, 0x69, 0x61, 0x73, 0x42, 0x0b, 0x5a, 0x09,
-	0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x78, 0x6c, 0x73, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x33,
+	0x78, 0x74, 0x3a, 0x3f, 0x0a, 0x0b, 0x65, 0x6e, 0x75, 0x6d, 0x5f, 0x66, 0x6f, 0x72, 0x6d, 0x61,
+	0x74, 0x12, 0x1d, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x18, 0xea, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x65, 0x6e, 0x75, 0x6d, 0x46, 0x6f, 0x72,
+	0x6d, 0x61, 0x74, 0x3a, 0x38, 0x0a, 0x05, 0x61, 0x6c, 0x69, 0x61, 0x73, 0x12, 0x21, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45,
+	0x6e, 0x75, 0x6d, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18,
+	0xe9, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x61, 0x6c, 0x69, 0x61, 0x73, 0x42, 0x0b, 0x5a,
+	0x09, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x78, 0x6c, 0x73, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
 }
 
 var file_option_proto_goTypes = []interface{}{
@@ -137,11 +151,12 @@ var file_option_proto_depIdxs = []int32{
 	0, // 2: table:extendee -> google.protobuf.MessageOptions
 	0, // 3: keys:extendee -> google.protobuf.MessageOptions
 	1, // 4: text:extendee -> google.protobuf.FieldOptions
-	2, // 5: alias:extendee -> google.protobuf.EnumValueOptions
-	6, // [6:6] is the sub-list for method output_type
-	6, // [6:6] is the sub-list for method input_type
-	6, // [6:6] is the sub-list for extension type_name
-	0, // [0:6] is the sub-list for extension extendee
+	1, // 5: enum_format:extendee -> google.protobuf.FieldOptions
+	2, // 6: alias:extendee -> google.protobuf.EnumValueOptions
+	7, // [7:7] is the sub-list for method output_type
+	7, // [7:7] is the sub-list for method input_type
+	7, // [7:7] is the sub-list for extension type_name
+	0, // [0:7] is the sub-list for extension extendee
 	0, // [0:0] is the sub-list for field type_name
 }
 
@@ -157,7 +172,7 @@ func file_option_proto_init() {
 			RawDescriptor: file_option_proto_rawDesc,
 			NumEnums:      0,
 			NumMessages:   0,
-			NumExtensions: 6,
+			NumExtensions: 7,
 			NumServices:   0,
 		},
 		GoTypes:           file_option_proto_goTypes,
@@ -3,6 +3,7 @@ package protoxls
 import (
 	"fmt"
 	"log"
+	"math"
 	"strconv"
 	"strings"
 
@@ -18,17 +19,27 @@ const (
 	DefaultArraySeparator = ","
 	// ColumnNameSeparator is the separator used between base prefix and column name
 	ColumnNameSeparator = "."
+	// RangeSeparator is the separator between the bounds of a range like "1-10"
+	RangeSeparator = "-"
+	// RangeStepSeparator is the separator between a range and its step like "0-100:10"
+	RangeStepSeparator = ":"
+	// MaxRangeExpansion limits the number of values a single range may expand to
+	MaxRangeExpansion = 10000
 )
 
-// buildFieldColumnName builds the column name for a field with optional base prefix
-func buildFieldColumnName(field *desc.FieldDescriptor, basePrefix string) string {
-	options := field.GetFieldOptions()
-	columnName := ""
-	if options != nil {
-		if ext, ok := proto.GetExtension(options, E_Text).(string); ok && ext != "" {
-			columnName = ext
+// getFieldText returns the (text) option of a field, or empty string if not set
+func getFieldText(field *desc.FieldDescriptor) string {
+	if options := field.GetFieldOptions(); options != nil {
+		if ext, ok := proto.GetExtension(options, E_Text).(string); ok {
+			return ext
 		}
 	}
+	return ""
+}
+
+// buildFieldColumnName builds the column name for a field with optional base prefix
+func buildFieldColumnName(field *desc.FieldDescriptor, basePrefix string) string {
+	columnName := getFieldText(field)
 	if columnName == "" {
 		columnName = field.GetName()
 	}
@@ -52,17 +63,26 @@ func columnExists(headerMap map[string]int, columnName string) bool {
 // parseFieldValue parses a single field value from Excel row
 func parseFieldValue(message *dynamic.Message, field *desc.FieldDescriptor, row []string, headerMap map[string]int, rowIndex int, basePrefix string) error {
 	columnName := buildFieldColumnName(field, basePrefix)
+
+	// Nested messages span one column per field unless a cell converter handles a single column
+	if field.GetType().String() == "TYPE_MESSAGE" && !(hasCellConverter(field) && columnExists(headerMap, columnName)) {
+		nestedMessage := dynamic.NewMessage(field.GetMessageType())
+		if err := parseMessage(nestedMessage, field.GetMessageType(), row, headerMap, rowIndex, columnName); err != nil {
+			return err
+		}
+		message.SetField(field, nestedMessage)
+		return nil
+	}
+
 	colIndex, ok := headerMap[columnName]
-	if !ok && field.GetType().String() != "TYPE_MESSAGE" {
+	if !ok {
 		return fmt.Errorf("column not found: %s", columnName)
 	}
-	if colIndex >= len(row) {
-		return fmt.Errorf("column index out of bounds for column %s: index %d >= row length %d", columnName, colIndex, len(row))
-	}
-	cellValue := row[colIndex]
-	if cellValue == "" {
+	// Rows end at their last non-empty cell, so a column past the end of the row is empty
+	if colIndex >= len(row) || row[colIndex] == "" {
 		return nil // 允许为空
 	}
+	cellValue := row[colIndex]
 
 	// Validate cell type
 	switch field.GetType().String() {
@@ -84,15 +104,6 @@ func parseFieldValue(message *dynamic.Message, field *desc.FieldDescriptor, row
 		return fmt.Errorf("failed to convert value at row %d, column %d (%s): %v", rowIndex+2, colIndex+1, columnName, err)
 	}
 
-	// Handle nested messages
-	if field.GetType().String() == "TYPE_MESSAGE" {
-		nestedMessage := dynamic.NewMessage(field.GetMessageType())
-		if err := parseMessage(nestedMessage, field.GetMessageType(), row, headerMap, rowIndex, columnName); err != nil {
-			return err
-		}
-		fieldValue = nestedMessage
-	}
-
 	message.SetField(field, fieldValue)
 	return nil
 }
@@ -140,6 +151,9 @@ func convertCellValue(cellValue string, field *desc.FieldDescriptor) (interface{
 	case "TYPE_STRING":
 		return cellValue, nil
 
+	case "TYPE_MESSAGE":
+		return convertCellMessage(cellValue, field.GetMessageType())
+
 	default:
 		return cellValue, nil
 	}
@@ -163,30 +177,36 @@ func parseEnumValue(cellValue string, field *desc.FieldDescriptor) (int32, error
 }
 
 // parseRepeatedFieldValue parses repeated field values from Excel row
-func parseRepeatedFieldValue(message *dynamic.Message, field *desc.FieldDescriptor, row []string, headerMap map[string]int, _ int, basePrefix string) error {
+func parseRepeatedFieldValue(message *dynamic.Message, field *desc.FieldDescriptor, row []string, headerMap map[string]int, rowIndex int, basePrefix string) error {
 	columnName := buildFieldColumnName(field, basePrefix)
 
 	// Try parsing as separator-delimited array first
 	if columnExists(headerMap, columnName) {
-		if err := parseDelimitedArray(message, field, row, headerMap, columnName); err != nil {
+		if err := parseDelimitedArray(message, field, row, headerMap, rowIndex, columnName); err != nil {
 			return err
 		}
 		return nil
 	}
 
 	// Try parsing as indexed columns: name[1], name[2], etc.
-	return parseIndexedArray(message, field, row, headerMap, columnName)
+	return parseIndexedArray(message, field, row, headerMap, rowIndex, columnName)
 }
 
 // parseDelimitedArray parses array values separated by delimiter
-func parseDelimitedArray(message *dynamic.Message, field *desc.FieldDescriptor, row []string, headerMap map[string]int, columnName string) error {
+func parseDelimitedArray(message *dynamic.Message, field *desc.FieldDescriptor, row []string, headerMap map[string]int, rowIndex int, columnName string) error {
 	colIndex := headerMap[columnName]
-	cellValue := row[colIndex]
-	if cellValue == "" {
+	if colIndex >= len(row) || row[colIndex] == "" {
 		return nil
 	}
+	cellValue := row[colIndex]
+
+	// Messages converted from a single cell may contain the default separator themselves
+	separator := DefaultArraySeparator
+	if field.GetType().String() == "TYPE_MESSAGE" {
+		separator = CompositeArraySeparator
+	}
 
-	values := strings.Split(cellValue, DefaultArraySeparator)
+	values := strings.Split(cellValue, separator)
 	for _, val := range values {
 		val = strings.TrimSpace(val)
 		if val == "" {
@@ -194,21 +214,161 @@ func parseDelimitedArray(message *dynamic.Message, field *desc.FieldDescriptor,
 		}
 
 		convertedValue, err := convertCellValue(val, field)
-		if err != nil {
+		if err == nil {
+			message.AddRepeatedField(field, convertedValue)
+			continue
+		}
+		if !supportsRangeSyntax(field) || !isRangeElement(val) {
 			return fmt.Errorf("failed to convert array element '%s': %v", val, err)
 		}
-		message.AddRepeatedField(field, convertedValue)
+
+		// Expand range elements like "1-10" or "0-100:10"
+		rangeValues, err := expandRangeElement(val, field)
+		if err != nil {
+			return fmt.Errorf("failed to expand array range '%s' at row %d, column %d (%s): %v", val, rowIndex+2, colIndex+1, columnName, err)
+		}
+		for _, rangeValue := range rangeValues {
+			message.AddRepeatedField(field, rangeValue)
+		}
 	}
 	return nil
 }
 
+// supportsRangeSyntax checks if delimited array elements of the field may use range syntax
+func supportsRangeSyntax(field *desc.FieldDescriptor) bool {
+	switch field.GetType().String() {
+	case "TYPE_INT32", "TYPE_SINT32", "TYPE_SFIXED32", "TYPE_UINT32", "TYPE_FIXED32",
+		"TYPE_INT64", "TYPE_SINT64", "TYPE_SFIXED64", "TYPE_UINT64", "TYPE_FIXED64",
+		"TYPE_ENUM":
+		return true
+	}
+	return false
+}
+
+// isRangeElement checks if an array element contains a range separator after its first character
+func isRangeElement(element string) bool {
+	return len(element) > 1 && strings.Contains(element[1:], RangeSeparator)
+}
+
+// splitRangeElement splits a range element into its lower bound, upper bound and optional step
+func splitRangeElement(element string) (string, string, string, error) {
+	bounds, step := element, ""
+	if stepIndex := strings.LastIndex(element, RangeStepSeparator); stepIndex >= 0 {
+		bounds, step = element[:stepIndex], strings.TrimSpace(element[stepIndex+1:])
+		if step == "" {
+			return "", "", "", fmt.Errorf("missing step after '%s'", RangeStepSeparator)
+		}
+	}
+
+	// Skip the first character so that a negative lower bound like "-5-5" is kept intact
+	sepIndex := -1
+	if len(bounds) > 1 {
+		if index := strings.Index(bounds[1:], RangeSeparator); index >= 0 {
+			sepIndex = index + 1
+		}
+	}
+	if sepIndex < 0 {
+		return "", "", "", fmt.Errorf("missing '%s' between range bounds", RangeSeparator)
+	}
+
+	lower := strings.TrimSpace(bounds[:sepIndex])
+	upper := strings.TrimSpace(bounds[sepIndex+1:])
+	if lower == "" || upper == "" {
+		return "", "", "", fmt.Errorf("range bounds must not be empty")
+	}
+	return lower, upper, step, nil
+}
+
+// parseRangeBound parses a single range bound as an integer or enum value
+func parseRangeBound(bound string, field *desc.FieldDescriptor) (int64, error) {
+	if field.GetType().String() == "TYPE_ENUM" {
+		enumValue, err := parseEnumValue(bound, field)
+		if err != nil {
+			return 0, err
+		}
+		return int64(enumValue), nil
+	}
+	value, err := strconv.ParseInt(bound, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid range bound: %s", bound)
+	}
+	return value, nil
+}
+
+// expandRangeElement expands a range element like "1-10" or "0-100:10" into converted field values
+func expandRangeElement(element string, field *desc.FieldDescriptor) ([]interface{}, error) {
+	lowerStr, upperStr, stepStr, err := splitRangeElement(element)
+	if err != nil {
+		return nil, err
+	}
+
+	lower, err := parseRangeBound(lowerStr, field)
+	if err != nil {
+		return nil, err
+	}
+	upper, err := parseRangeBound(upperStr, field)
+	if err != nil {
+		return nil, err
+	}
+	if lower > upper {
+		return nil, fmt.Errorf("lower bound %s is greater than upper bound %s", lowerStr, upperStr)
+	}
+
+	step := int64(1)
+	if stepStr != "" {
+		step, err = strconv.ParseInt(stepStr, 10, 64)
+		if err != nil || step <= 0 {
+			return nil, fmt.Errorf("invalid range step: %s", stepStr)
+		}
+	}
+
+	count := (uint64(upper-lower) / uint64(step)) + 1
+	if count > MaxRangeExpansion {
+		return nil, fmt.Errorf("range expands to %d values, exceeding the limit of %d", count, MaxRangeExpansion)
+	}
+
+	values := make([]interface{}, 0, count)
+	for i := uint64(0); i < count; i++ {
+		value := lower + int64(i)*step
+		switch field.GetType().String() {
+		case "TYPE_INT64", "TYPE_SINT64", "TYPE_SFIXED64", "TYPE_UINT64", "TYPE_FIXED64":
+			values = append(values, value)
+		case "TYPE_ENUM":
+			if field.GetEnumType().FindValueByNumber(int32(value)) == nil {
+				return nil, fmt.Errorf("enum value %d not defined in %s", value, field.GetEnumType().GetName())
+			}
+			values = append(values, int32(value))
+		default:
+			if value < math.MinInt32 || value > math.MaxInt32 {
+				return nil, fmt.Errorf("range value %d overflows int32", value)
+			}
+			values = append(values, int32(value))
+		}
+	}
+	return values, nil
+}
+
 // parseIndexedArray parses array values from indexed columns
-func parseIndexedArray(message *dynamic.Message, field *desc.FieldDescriptor, row []string, headerMap map[string]int, baseColumnName string) error {
+func parseIndexedArray(message *dynamic.Message, field *desc.FieldDescriptor, row []string, headerMap map[string]int, rowIndex int, baseColumnName string) error {
 	for index := 1; ; index++ {
 		elementColumnName := buildArrayElementColumnName(baseColumnName, index)
 		
-		// For message types, check if any sub-field exists for this index
-		if field.GetType().String() == "TYPE_MESSAGE" {
+		// For message types with a cell converter, each index may be a single column
+		if field.GetType().String() == "TYPE_MESSAGE" && hasCellConverter(field) && columnExists(headerMap, elementColumnName) {
+			// Rows end at their last non-empty cell, so a missing cell is empty
+			colIndex := headerMap[elementColumnName]
+			if colIndex >= len(row) || row[colIndex] == "" {
+				continue
+			}
+			cellValue := row[colIndex]
+
+			nestedMessage, err := convertCellMessage(cellValue, field.GetMessageType())
+			if err != nil {
+				return fmt.Errorf("failed to convert array element '%s' at index %d: %v", cellValue, index, err)
+			}
+			message.AddRepeatedField(field, nestedMessage)
+		} else if field.GetType().String() == "TYPE_MESSAGE" {
+			// For message types, check if any sub-field exists for this index
 			// Check if any field of this message exists with this index
 			hasAnyField := false
 			for _, subField := range field.GetMessageType().GetFields() {
@@ -224,7 +384,7 @@ func parseIndexedArray(message *dynamic.Message, field *desc.FieldDescriptor, ro
 			
 			// Create nested message for this index
 			nestedMessage := dynamic.NewMessage(field.GetMessageType())
-			if err := parseMessage(nestedMessage, field.GetMessageType(), row, headerMap, 0, elementColumnName); err != nil {
+			if err := parseMessage(nestedMessage, field.GetMessageType(), row, headerMap, rowIndex, elementColumnName); err != nil {
 				return fmt.Errorf("failed to parse nested message at index %d: %v", index, err)
 			}
 			message.AddRepeatedField(field, nestedMessage)
@@ -235,10 +395,10 @@ func parseIndexedArray(message *dynamic.Message, field *desc.FieldDescriptor, ro
 			}
 
 			colIndex := headerMap[elementColumnName]
-			cellValue := row[colIndex]
-			if cellValue == "" {
+			if colIndex >= len(row) || row[colIndex] == "" {
 				continue
 			}
+			cellValue := row[colIndex]
 
 			convertedValue, err := convertCellValue(cellValue, field)
 			if err != nil {
@@ -271,27 +431,61 @@ func parseMessage(message *dynamic.Message, msgDesc *desc.MessageDescriptor, row
 
 // ExportConfig holds configuration for different export formats
 type ExportConfig struct {
-	LuaOutput     string // Output directory for Lua files
-	JsonOutput    string // Output directory for JSON files
-	BinOutput     string // Output directory for Binary files
-	YamlOutput    string // Output directory for YAML files
-	PhpOutput     string // Output directory for PHP files
-	CompactFormat bool   // Whether to compress each data entry to a single line
+	LuaOutput           string // Output directory for Lua files
+	JsonOutput          string // Output directory for JSON files
+	BinOutput           string // Output directory for Binary files
+	YamlOutput          string // Output directory for YAML files
+	PhpOutput           string // Output directory for PHP files
+	JsonSchemaOutput    string // Output directory for JSON Schema files
+	JsonLinesOutput     string // Output directory for JSON Lines files
+	TextProtoOutput     string // Output directory for protobuf text format files
+	CSharpOutput        string // Output directory for C# classes and loaders
+	CSharpNamespace     string // Namespace of the generated C# code, derived from each proto file if empty
+	CSharpNaming        string // C# naming convention: CSharpNamingPascal or CSharpNamingProto
+	CSharpLoader        string // Output read by the C# loaders: CSharpLoaderJSON or CSharpLoaderBinary
+	GoOutput            string // Output directory for Go structs and loaders
+	GoPackage           string // Package name of the generated Go code, derived from GoOutput if empty
+	GoLoader            string // Output read by the Go loaders: GoLoaderJSON or GoLoaderBinary
+	TsOutput            string // Output directory for TypeScript declarations and modules
+	TsMode              string // TypeScript output mode: TsModeTypes or TsModeModule
+	PyOutput            string // Output directory for Python modules
+	PyTypes             string // Python type definitions: PyTypesDataclass or PyTypesTypedDict
+	JsonLinesKey        string // Field name of the key path in JSON Lines rows, omitted if empty
+	GoEmbed             bool   // Whether to embed the data in the generated Go package with go:embed
+	CompactFormat       bool   // Whether to compress each data entry to a single line
+	LuaModule           bool   // Whether to emit Lua files as modules returning a local table
+	LuaReadOnly         bool   // Whether to wrap exported Lua tables in read-only proxies
+	LuaOptimize         bool   // Whether to omit default values and share identical subtables in Lua files
+	LuaChunkConstants   int    // Maximum estimated constants per generated Lua function, MaxLuaChunkConstants if 0
+	LuaAnnotations      bool   // Whether to generate EmmyLua / LuaLS annotation files next to the Lua files
+	JsonStyle           string // JSON mapping style: JsonStyleDefault or JsonStyleProtoJSON
+	ExportEnums         bool   // Whether to write the enum definitions used by the tables next to the data files
+	PhpEnumStyle        string // How PHP enum definitions are written: PhpEnumStyleClass or PhpEnumStyleEnum
+	EnumFormat          string // How enum fields are exported unless set per field: EnumFormatNumber, EnumFormatName or EnumFormatAlias
+	BinFormat           string // Layout of binary files: BinFormatRaw, BinFormatContainer, BinFormatWrapper or BinFormatIndexed
+	BinDescriptors      bool   // Whether binary containers embed the FileDescriptorSet of the table
+	DescriptorSetOutput string // Output file for the FileDescriptorSet of the files defining the tables
+	IncludeSourceInfo   bool   // Whether the descriptor set keeps source info such as comments
+	DescriptorSet       string // Serialized FileDescriptorSet to load the schema from instead of the .proto file
 }
 
-// ParseProtoFiles parses proto files and generates configuration tables with custom export configuration
-func ParseProtoFiles(protoFile string, importPaths []string, exportConfig *ExportConfig) error {
+// LoadProtoFiles parses proto files into file descriptors, keeping source info for comments
+func LoadProtoFiles(protoFile string, importPaths []string) ([]*desc.FileDescriptor, error) {
 	parser := protoparse.Parser{
-		ImportPaths: importPaths,
+		ImportPaths:           importPaths,
+		IncludeSourceCodeInfo: true,
 	}
 
 	fileDescriptors, err := parser.ParseFiles(protoFile)
 	if err != nil {
-		return fmt.Errorf("failed to parse proto file %s: %v", protoFile, err)
+		return nil, fmt.Errorf("failed to parse proto file %s: %v", protoFile, err)
 	}
+	return fileDescriptors, nil
+}
 
-	var configStores []*TableStore
-
+// FindExcelMessages returns the top-level messages that have the excel option
+func FindExcelMessages(fileDescriptors []*desc.FileDescriptor) []*desc.MessageDescriptor {
+	var messages []*desc.MessageDescriptor
 	for _, fd := range fileDescriptors {
 		for _, md := range fd.GetMessageTypes() {
 			// Only process messages that have Excel options
@@ -299,43 +493,78 @@ func ParseProtoFiles(protoFile string, importPaths []string, exportConfig *Expor
 			if options == nil {
 				continue
 			}
-			
+
 			// Check if message has excel option
 			if _, ok := proto.GetExtension(options, E_Excel).(string); !ok {
 				continue
 			}
-			
-			store, err := parseExcelToTableStore(md)
-			if err != nil {
-				return fmt.Errorf("failed to generate table for message %s: %v", md.GetName(), err)
-			}
-			configStores = append(configStores, store)
+			messages = append(messages, md)
+		}
+	}
+	return messages
+}
+
+// ParseProtoFiles parses proto files and generates configuration tables with custom export configuration.
+// If the configuration names a descriptor set, the schema is loaded from it and the proto file is not read
+func ParseProtoFiles(protoFile string, importPaths []string, exportConfig *ExportConfig) error {
+	var fileDescriptors []*desc.FileDescriptor
+	var err error
+	if exportConfig.DescriptorSet != "" {
+		fileDescriptors, err = LoadDescriptorSet(exportConfig.DescriptorSet)
+	} else {
+		fileDescriptors, err = LoadProtoFiles(protoFile, importPaths)
+	}
+	if err != nil {
+		return err
+	}
+
+	var configStores []*TableStore
+
+	for _, md := range FindExcelMessages(fileDescriptors) {
+		store, err := parseExcelToTableStore(md)
+		if err != nil {
+			return fmt.Errorf("failed to generate table for message %s: %v", md.GetName(), err)
 		}
+		configStores = append(configStores, store)
 	}
 
 	// Export results to specified formats
 	return ExportTableStores(configStores, exportConfig)
 }
 
-// parseExcelToTableStore parses Excel file data into TableStore
-func parseExcelToTableStore(msgDesc *desc.MessageDescriptor) (*TableStore, error) {
-	// Parse table configuration from message options
+// getExcelOptions extracts the Excel file path and sheet name from message options
+func getExcelOptions(msgDesc *desc.MessageDescriptor) (string, string, error) {
 	options := msgDesc.GetMessageOptions()
 	if options == nil {
-		return nil, fmt.Errorf("message %s has no options", msgDesc.GetName())
+		return "", "", fmt.Errorf("message %s has no options", msgDesc.GetName())
 	}
 
 	// Extract Excel file path
 	excelPath, ok := proto.GetExtension(options, E_Excel).(string)
 	if !ok || excelPath == "" {
-		return nil, fmt.Errorf("message %s missing excel option", msgDesc.GetName())
+		return "", "", fmt.Errorf("message %s missing excel option", msgDesc.GetName())
 	}
 
 	// Extract sheet name
 	sheetName, ok := proto.GetExtension(options, E_Sheet).(string)
 	if !ok || sheetName == "" {
-		return nil, fmt.Errorf("message %s missing sheet option", msgDesc.GetName())
+		return "", "", fmt.Errorf("message %s missing sheet option", msgDesc.GetName())
+	}
+
+	return excelPath, sheetName, nil
+}
+
+// parseExcelToTableStore parses Excel file data into TableStore
+func parseExcelToTableStore(msgDesc *desc.MessageDescriptor) (*TableStore, error) {
+	// Parse table configuration from message options
+	excelPath, sheetName, err := getExcelOptions(msgDesc)
+	if err != nil {
+		return nil, err
 	}
+	if err := checkEnumFormats(msgDesc); err != nil {
+		return nil, err
+	}
+	options := msgDesc.GetMessageOptions()
 
 	// Extract optional key configuration
 	keysConfig := ""
@@ -399,24 +628,48 @@ func ExportTableStores(stores []*TableStore, exportConfig *ExportConfig) error {
 
 	// Add exporters based on configuration
 	if exportConfig.LuaOutput != "" {
-		exporters = append(exporters, &LuaExporter{OutputDir: exportConfig.LuaOutput, CompactFormat: exportConfig.CompactFormat})
+		exporters = append(exporters, &LuaExporter{OutputDir: exportConfig.LuaOutput, CompactFormat: exportConfig.CompactFormat, ModuleFormat: exportConfig.LuaModule, ReadOnly: exportConfig.LuaReadOnly, Optimize: exportConfig.LuaOptimize, ChunkConstants: exportConfig.LuaChunkConstants, EnumFormat: exportConfig.EnumFormat})
+	}
+	if exportConfig.LuaOutput != "" && exportConfig.LuaAnnotations {
+		exporters = append(exporters, &LuaAnnotationExporter{OutputDir: exportConfig.LuaOutput, ModuleFormat: exportConfig.LuaModule, EnumFormat: exportConfig.EnumFormat})
 	}
 	if exportConfig.JsonOutput != "" {
-		exporters = append(exporters, &JsonExporter{OutputDir: exportConfig.JsonOutput, CompactFormat: exportConfig.CompactFormat})
+		exporters = append(exporters, &JsonExporter{OutputDir: exportConfig.JsonOutput, CompactFormat: exportConfig.CompactFormat, Style: exportConfig.JsonStyle, EnumFormat: exportConfig.EnumFormat})
 	}
 	if exportConfig.BinOutput != "" {
-		exporters = append(exporters, &BinExporter{OutputDir: exportConfig.BinOutput})
+		exporters = append(exporters, &BinExporter{OutputDir: exportConfig.BinOutput, Format: exportConfig.BinFormat, EmbedDescriptors: exportConfig.BinDescriptors})
 	}
 	if exportConfig.YamlOutput != "" {
-		exporters = append(exporters, &YamlExporter{OutputDir: exportConfig.YamlOutput})
+		exporters = append(exporters, &YamlExporter{OutputDir: exportConfig.YamlOutput, EnumFormat: exportConfig.EnumFormat})
 	}
 	if exportConfig.PhpOutput != "" {
-		exporters = append(exporters, &PhpExporter{OutputDir: exportConfig.PhpOutput, CompactFormat: exportConfig.CompactFormat})
+		exporters = append(exporters, &PhpExporter{OutputDir: exportConfig.PhpOutput, CompactFormat: exportConfig.CompactFormat, EnumStyle: exportConfig.PhpEnumStyle, EnumFormat: exportConfig.EnumFormat})
+	}
+	if exportConfig.JsonSchemaOutput != "" {
+		exporters = append(exporters, &JsonSchemaExporter{OutputDir: exportConfig.JsonSchemaOutput, Style: exportConfig.JsonStyle, EnumFormat: exportConfig.EnumFormat})
+	}
+	if exportConfig.JsonLinesOutput != "" {
+		exporters = append(exporters, &JsonLinesExporter{OutputDir: exportConfig.JsonLinesOutput, Style: exportConfig.JsonStyle, KeyField: exportConfig.JsonLinesKey, EnumFormat: exportConfig.EnumFormat})
+	}
+	if exportConfig.TextProtoOutput != "" {
+		exporters = append(exporters, &TextProtoExporter{OutputDir: exportConfig.TextProtoOutput})
+	}
+	if exportConfig.CSharpOutput != "" {
+		exporters = append(exporters, &CSharpExporter{OutputDir: exportConfig.CSharpOutput, Namespace: exportConfig.CSharpNamespace, Naming: exportConfig.CSharpNaming, Loader: exportConfig.CSharpLoader, JsonStyle: exportConfig.JsonStyle, EnumFormat: exportConfig.EnumFormat})
+	}
+	if exportConfig.GoOutput != "" {
+		exporters = append(exporters, &GoExporter{OutputDir: exportConfig.GoOutput, Package: exportConfig.GoPackage, Loader: exportConfig.GoLoader, Embed: exportConfig.GoEmbed, JsonStyle: exportConfig.JsonStyle, EnumFormat: exportConfig.EnumFormat})
+	}
+	if exportConfig.TsOutput != "" {
+		exporters = append(exporters, &TsExporter{OutputDir: exportConfig.TsOutput, Mode: exportConfig.TsMode, JsonStyle: exportConfig.JsonStyle, EnumFormat: exportConfig.EnumFormat})
+	}
+	if exportConfig.PyOutput != "" {
+		exporters = append(exporters, &PyExporter{OutputDir: exportConfig.PyOutput, Types: exportConfig.PyTypes})
 	}
 
-	// If no exporters specified, default to JSON
-	if len(exporters) == 0 {
-		exporters = append(exporters, &JsonExporter{OutputDir: DefaultOutputDir, CompactFormat: exportConfig.CompactFormat})
+	// If no outputs specified, default to JSON
+	if len(exporters) == 0 && exportConfig.DescriptorSetOutput == "" {
+		exporters = append(exporters, &JsonExporter{OutputDir: DefaultOutputDir, CompactFormat: exportConfig.CompactFormat, Style: exportConfig.JsonStyle, EnumFormat: exportConfig.EnumFormat})
 	}
 
 	for _, store := range stores {
@@ -427,5 +680,22 @@ func ExportTableStores(stores []*TableStore, exportConfig *ExportConfig) error {
 		}
 	}
 
+	if exportConfig.ExportEnums {
+		enums := CollectEnums(stores)
+		for _, exporter := range exporters {
+			if enumExporter, ok := exporter.(EnumExporter); ok {
+				if err := enumExporter.ExportEnums(enums); err != nil {
+					log.Printf("Failed to export enums with %T: %v", exporter, err)
+				}
+			}
+		}
+	}
+
+	if exportConfig.DescriptorSetOutput != "" {
+		if err := WriteDescriptorSet(stores, exportConfig.DescriptorSetOutput, exportConfig.IncludeSourceInfo); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
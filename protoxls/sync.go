@@ -0,0 +1,442 @@
+package protoxls
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	// ColumnMetaSheet is the hidden sheet mapping header columns to field number paths
+	ColumnMetaSheet = "protoxls_meta"
+	// RemovedColumnComment is the comment added to header columns of fields removed from the schema
+	RemovedColumnComment = "This field was removed from the schema"
+)
+
+// arrayIndexPattern matches the element index of an array in a field path like "16[2].1"
+var arrayIndexPattern = regexp.MustCompile(`\[(\d+)\]`)
+
+// SyncConfig holds configuration for syncing workbook headers with the schema
+type SyncConfig struct {
+	MessageName   string // Only sync the workbook of this message, all excel messages if empty
+	ArraySlots    int    // Number of indexed column groups for newly added message arrays
+	RemoveDeleted bool   // Whether to remove columns of deleted fields instead of flagging them
+	DryRun        bool   // Whether to only print the planned changes without saving
+}
+
+// readColumnMeta reads the header to field path mapping recorded for a sheet
+func readColumnMeta(excelFile *excelize.File, sheetName string) (map[string]string, error) {
+	meta := make(map[string]string)
+	index, err := excelFile.GetSheetIndex(ColumnMetaSheet)
+	if err != nil || index < 0 {
+		return meta, err
+	}
+
+	rows, err := excelFile.GetRows(ColumnMetaSheet)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if len(row) >= 3 && row[0] == sheetName {
+			meta[row[2]] = row[1]
+		}
+	}
+	return meta, nil
+}
+
+// writeColumnMeta replaces the header to field path mapping recorded for a sheet
+func writeColumnMeta(excelFile *excelize.File, sheetName string, columns []schemaColumn) error {
+	var rows [][]string
+	index, err := excelFile.GetSheetIndex(ColumnMetaSheet)
+	if err != nil {
+		return err
+	}
+	if index >= 0 {
+		// Keep the entries of other sheets
+		existingRows, err := excelFile.GetRows(ColumnMetaSheet)
+		if err != nil {
+			return err
+		}
+		for _, row := range existingRows {
+			if len(row) > 0 && row[0] != sheetName {
+				rows = append(rows, row)
+			}
+		}
+		if err := excelFile.DeleteSheet(ColumnMetaSheet); err != nil {
+			return err
+		}
+	}
+
+	for _, column := range columns {
+		rows = append(rows, []string{sheetName, column.Path, column.Name})
+	}
+
+	if _, err := excelFile.NewSheet(ColumnMetaSheet); err != nil {
+		return err
+	}
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return err
+		}
+		if err := excelFile.SetSheetRow(ColumnMetaSheet, cell, &row); err != nil {
+			return err
+		}
+	}
+	return excelFile.SetSheetVisible(ColumnMetaSheet, false)
+}
+
+// existingArraySlots returns an arraySlotsFunc keeping the number of indexed columns found in a workbook
+func existingArraySlots(paths []string, defaultSlots int) arraySlotsFunc {
+	maxSlots := make(map[string]int)
+	for _, path := range paths {
+		for _, match := range arrayIndexPattern.FindAllStringSubmatchIndex(path, -1) {
+			index, _ := strconv.Atoi(path[match[2]:match[3]])
+			arrayPath := path[:match[0]]
+			if index > maxSlots[arrayPath] {
+				maxSlots[arrayPath] = index
+			}
+		}
+	}
+
+	defaultFunc := fixedArraySlots(defaultSlots)
+	return func(path string) (int, bool) {
+		if slots, ok := maxSlots[path]; ok {
+			return slots, true
+		}
+		return defaultFunc(path)
+	}
+}
+
+// resolveColumnPath resolves the field number path of a header written with text options, field names or
+// field numbers, so headers that are still spelled by field name or path can be matched without metadata
+func resolveColumnPath(msgDesc *desc.MessageDescriptor, header string) string {
+	for _, field := range msgDesc.GetFields() {
+		if field.IsMap() {
+			continue
+		}
+
+		for _, name := range []string{buildFieldColumnName(field, ""), field.GetName(), strconv.Itoa(int(field.GetNumber()))} {
+			if !strings.HasPrefix(header, name) {
+				continue
+			}
+			rest := header[len(name):]
+			path := strconv.Itoa(int(field.GetNumber()))
+
+			// Indexed array element like "name[2]"
+			if field.IsRepeated() && strings.HasPrefix(rest, "[") {
+				end := strings.Index(rest, "]")
+				if end < 0 {
+					continue
+				}
+				index, err := strconv.Atoi(rest[1:end])
+				if err != nil || index < 1 {
+					continue
+				}
+				path = buildArrayElementColumnName(path, index)
+				rest = rest[end+1:]
+			}
+
+			if rest == "" {
+				return path
+			}
+			if field.GetType().String() == "TYPE_MESSAGE" && strings.HasPrefix(rest, ColumnNameSeparator) {
+				if subPath := resolveColumnPath(field.GetMessageType(), rest[len(ColumnNameSeparator):]); subPath != "" {
+					return path + ColumnNameSeparator + subPath
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// SyncWorkbookFiles parses proto files and syncs the headers of each table workbook with the schema
+func SyncWorkbookFiles(protoFile string, importPaths []string, config *SyncConfig) error {
+	fileDescriptors, err := LoadProtoFiles(protoFile, importPaths)
+	if err != nil {
+		return err
+	}
+
+	workbookPaths, workbookMessages, err := groupMessagesByWorkbook(FindExcelMessages(fileDescriptors), config.MessageName, "")
+	if err != nil {
+		return err
+	}
+
+	for _, excelPath := range workbookPaths {
+		if err := SyncWorkbook(workbookMessages[excelPath], excelPath, config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SyncWorkbook syncs the header rows of the message sheets in a workbook without touching data cells
+func SyncWorkbook(messages []*desc.MessageDescriptor, excelPath string, config *SyncConfig) error {
+	excelFile, err := excelize.OpenFile(excelPath)
+	if err != nil {
+		return fmt.Errorf("failed to open excel file %s: %v", excelPath, err)
+	}
+	defer excelFile.Close()
+
+	changed := false
+	for _, md := range messages {
+		_, sheetName, err := getExcelOptions(md)
+		if err != nil {
+			return err
+		}
+		sheetChanged, err := syncSheet(excelFile, sheetName, md, config)
+		if err != nil {
+			return fmt.Errorf("failed to sync sheet %s of %s: %v", sheetName, excelPath, err)
+		}
+		changed = changed || sheetChanged
+	}
+
+	if config.DryRun || !changed {
+		fmt.Printf("No changes written to %s\n", excelPath)
+		return nil
+	}
+	if err := excelFile.Save(); err != nil {
+		return fmt.Errorf("failed to save excel file %s: %v", excelPath, err)
+	}
+	fmt.Printf("Synced excel file: %s\n", excelPath)
+	return nil
+}
+
+// syncSheet renames, inserts and flags header columns of a sheet to match the message schema
+func syncSheet(excelFile *excelize.File, sheetName string, msgDesc *desc.MessageDescriptor, config *SyncConfig) (bool, error) {
+	rows, err := excelFile.GetRows(sheetName)
+	if err != nil {
+		return false, err
+	}
+	var headers []string
+	if len(rows) > 0 {
+		headers = rows[0]
+	}
+
+	meta, err := readColumnMeta(excelFile, sheetName)
+	if err != nil {
+		return false, fmt.Errorf("failed to read column metadata: %v", err)
+	}
+
+	// Resolve the field path of each existing header, by recorded metadata or by current names
+	headerPaths := make([]string, len(headers))
+	var knownPaths []string
+	for i, header := range headers {
+		if path, ok := meta[header]; ok {
+			headerPaths[i] = path
+		} else {
+			headerPaths[i] = resolveColumnPath(msgDesc, header)
+		}
+		if headerPaths[i] != "" {
+			knownPaths = append(knownPaths, headerPaths[i])
+		}
+	}
+
+	columns := collectSchemaColumns(msgDesc, "", "", "", existingArraySlots(knownPaths, config.ArraySlots))
+	expectedPaths := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		expectedPaths[column.Path] = true
+	}
+
+	// positions holds the current 1-based column index of every resolved field path
+	positions := make(map[string]int)
+	for i, path := range headerPaths {
+		if path != "" {
+			if _, exists := positions[path]; !exists {
+				positions[path] = i + 1
+			}
+		}
+	}
+
+	// Without recorded metadata a header whose (text) changed matches no field. Inserting the field again
+	// would leave its data in a column the exporter ignores, so stop until the header is matched
+	if len(meta) == 0 {
+		var unknownHeaders, missingColumns []string
+		for i, path := range headerPaths {
+			if path == "" && headers[i] != "" {
+				unknownHeaders = append(unknownHeaders, strconv.Quote(headers[i]))
+			}
+		}
+		for _, column := range columns {
+			if _, exists := positions[column.Path]; !exists {
+				missingColumns = append(missingColumns, strconv.Quote(column.Name))
+			}
+		}
+		if len(unknownHeaders) > 0 && len(missingColumns) > 0 {
+			return false, fmt.Errorf("columns %s match no field and columns %s are missing, but there are no %s entries "+
+				"to tell renamed columns from new ones; change those headers to the new text, the field name or the field "+
+				"number path, or delete them, and sync again",
+				strings.Join(unknownHeaders, ", "), strings.Join(missingColumns, ", "), ColumnMetaSheet)
+		}
+	}
+
+	headerStyle, err := newHeaderStyle(excelFile)
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	lastCol := 0
+	for _, column := range columns {
+		colIndex, exists := positions[column.Path]
+		if exists {
+			// Rename headers whose text changed
+			if headers[colIndex-1] != column.Name {
+				fmt.Printf("[%s] rename column %q -> %q\n", sheetName, headers[colIndex-1], column.Name)
+				if !config.DryRun {
+					cell, _ := excelize.CoordinatesToCellName(colIndex, 1)
+					if err := excelFile.SetCellStr(sheetName, cell, column.Name); err != nil {
+						return false, err
+					}
+				}
+				changed = true
+			}
+			if colIndex > lastCol {
+				lastCol = colIndex
+			}
+			continue
+		}
+
+		// Insert new fields right after the previous schema column
+		colIndex = lastCol + 1
+		fmt.Printf("[%s] insert column %q\n", sheetName, column.Name)
+		if !config.DryRun {
+			colName, err := excelize.ColumnNumberToName(colIndex)
+			if err != nil {
+				return false, err
+			}
+			if colIndex <= len(headers) {
+				if err := excelFile.InsertCols(sheetName, colName, 1); err != nil {
+					return false, err
+				}
+			}
+			if err := writeTemplateHeader(excelFile, sheetName, colIndex, column, headerStyle); err != nil {
+				return false, err
+			}
+		}
+		for path, index := range positions {
+			if index >= colIndex {
+				positions[path] = index + 1
+			}
+		}
+		headers = append(headers[:colIndex-1], append([]string{column.Name}, headers[colIndex-1:]...)...)
+		headerPaths = append(headerPaths[:colIndex-1], append([]string{column.Path}, headerPaths[colIndex-1:]...)...)
+		positions[column.Path] = colIndex
+		lastCol = colIndex
+		changed = true
+	}
+
+	// Flag or remove columns of fields that no longer exist, from right to left to keep indexes valid
+	var removedCols []int
+	for i, path := range headerPaths {
+		if path != "" && !expectedPaths[path] {
+			removedCols = append(removedCols, i+1)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(removedCols)))
+
+	comments, err := excelFile.GetComments(sheetName)
+	if err != nil {
+		return false, err
+	}
+
+	// Flagged columns stay in the metadata so that a later sync can still remove them
+	metaColumns := columns
+	for _, colIndex := range removedCols {
+		colName, err := excelize.ColumnNumberToName(colIndex)
+		if err != nil {
+			return false, err
+		}
+		header := headers[colIndex-1]
+		if config.RemoveDeleted {
+			fmt.Printf("[%s] remove column %q\n", sheetName, header)
+			if !config.DryRun {
+				if err := excelFile.RemoveCol(sheetName, colName); err != nil {
+					return false, err
+				}
+			}
+			changed = true
+			continue
+		}
+
+		metaColumns = append(metaColumns, schemaColumn{Name: header, Path: headerPaths[colIndex-1]})
+		if isColumnFlagged(comments, colName+"1") {
+			continue
+		}
+		fmt.Printf("[%s] flag removed column %q\n", sheetName, header)
+		if !config.DryRun {
+			if err := flagRemovedColumn(excelFile, sheetName, colName+"1"); err != nil {
+				return false, err
+			}
+		}
+		changed = true
+	}
+
+	// Report headers that do not belong to the schema at all
+	for i, path := range headerPaths {
+		if path == "" && headers[i] != "" {
+			fmt.Printf("[%s] unknown column %q left unchanged\n", sheetName, headers[i])
+		}
+	}
+
+	// Refresh the recorded field paths when they are missing or stale
+	metaChanged := len(meta) != len(metaColumns)
+	for _, column := range metaColumns {
+		if meta[column.Name] != column.Path {
+			metaChanged = true
+		}
+	}
+	if metaChanged && !config.DryRun {
+		if err := writeColumnMeta(excelFile, sheetName, metaColumns); err != nil {
+			return false, fmt.Errorf("failed to write column metadata: %v", err)
+		}
+	}
+	return changed || metaChanged, nil
+}
+
+// isColumnFlagged checks if a header cell already carries the removed field comment
+func isColumnFlagged(comments []excelize.Comment, cell string) bool {
+	for _, comment := range comments {
+		if comment.Cell != cell {
+			continue
+		}
+		if strings.Contains(comment.Text, RemovedColumnComment) {
+			return true
+		}
+		for _, run := range comment.Paragraph {
+			if strings.Contains(run.Text, RemovedColumnComment) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// flagRemovedColumn highlights the header cell of a removed field and explains why
+func flagRemovedColumn(excelFile *excelize.File, sheetName, cell string) error {
+	removedStyle, err := excelFile.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true, Strike: true, Color: "9C0006"},
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"FFC7CE"}},
+	})
+	if err != nil {
+		return err
+	}
+	if err := excelFile.SetCellStyle(sheetName, cell, cell, removedStyle); err != nil {
+		return err
+	}
+
+	// Replace any existing comment so repeated syncs do not stack notes
+	if err := excelFile.DeleteComment(sheetName, cell); err != nil {
+		return err
+	}
+	return excelFile.AddComment(sheetName, excelize.Comment{
+		Author: TemplateCommentAuthor,
+		Cell:   cell,
+		Text:   RemovedColumnComment,
+	})
+}
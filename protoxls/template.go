@@ -0,0 +1,374 @@
+package protoxls
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/xuri/excelize/v2"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// DefaultTemplateArraySlots is the default number of indexed column groups for message arrays
+	DefaultTemplateArraySlots = 2
+	// TemplateCommentAuthor is the author shown on generated header comments
+	TemplateCommentAuthor = "protoxls"
+	// TemplateEnumSheet is the hidden sheet holding enum lists too long for inline dropdowns
+	TemplateEnumSheet = "protoxls_enums"
+)
+
+// TemplateConfig holds configuration for generating Excel templates
+type TemplateConfig struct {
+	OutputPath  string // Output workbook path, defaults to the excel option of each message
+	MessageName string // Only generate the template for this message, all excel messages if empty
+	ArraySlots  int    // Number of indexed column groups for message arrays
+	Overwrite   bool   // Whether to overwrite existing workbooks
+}
+
+// schemaColumn describes a header column derived from the message schema
+type schemaColumn struct {
+	Name    string                // Header text as matched by parseMessage
+	RawName string                // Header text built from proto field names, ignoring text options
+	Path    string                // Field number path like "16[1].1", stable across renames
+	Field   *desc.FieldDescriptor // Field whose value is stored in the column
+}
+
+// arraySlotsFunc returns the number of indexed columns for the array at a field path, and whether
+// the array is explicitly indexed; other scalar arrays use a single delimited column
+type arraySlotsFunc func(path string) (int, bool)
+
+// fixedArraySlots returns an arraySlotsFunc using the same number of column groups for every message array
+func fixedArraySlots(arraySlots int) arraySlotsFunc {
+	if arraySlots <= 0 {
+		arraySlots = DefaultTemplateArraySlots
+	}
+	return func(string) (int, bool) {
+		return arraySlots, false
+	}
+}
+
+// collectSchemaColumns lists the header columns of a message in the layout read by parseMessage
+func collectSchemaColumns(msgDesc *desc.MessageDescriptor, basePrefix, baseRawPrefix, basePath string, arraySlots arraySlotsFunc) []schemaColumn {
+	var columns []schemaColumn
+	for _, field := range msgDesc.GetFields() {
+		if field.IsMap() {
+			// Map fields are not supported by the parser
+			continue
+		}
+
+		columnName := buildFieldColumnName(field, basePrefix)
+		rawColumnName := field.GetName()
+		if baseRawPrefix != "" {
+			rawColumnName = baseRawPrefix + ColumnNameSeparator + rawColumnName
+		}
+		path := fmt.Sprintf("%d", field.GetNumber())
+		if basePath != "" {
+			path = basePath + ColumnNameSeparator + path
+		}
+
+		isMessage := field.GetType().String() == "TYPE_MESSAGE" && !hasCellConverter(field)
+		slots, indexed := arraySlots(path)
+		switch {
+		case field.IsRepeated() && isMessage:
+			// Message arrays use one column group per index: name[1].field, name[2].field
+			for index := 1; index <= slots; index++ {
+				elementColumnName := buildArrayElementColumnName(columnName, index)
+				elementRawColumnName := buildArrayElementColumnName(rawColumnName, index)
+				elementPath := buildArrayElementColumnName(path, index)
+				columns = append(columns, collectSchemaColumns(field.GetMessageType(), elementColumnName, elementRawColumnName, elementPath, arraySlots)...)
+			}
+		case field.IsRepeated() && indexed:
+			// Scalar arrays already laid out as indexed columns: name[1], name[2]
+			for index := 1; index <= slots; index++ {
+				columns = append(columns, schemaColumn{
+					Name:    buildArrayElementColumnName(columnName, index),
+					RawName: buildArrayElementColumnName(rawColumnName, index),
+					Path:    buildArrayElementColumnName(path, index),
+					Field:   field,
+				})
+			}
+		case isMessage:
+			columns = append(columns, collectSchemaColumns(field.GetMessageType(), columnName, rawColumnName, path, arraySlots)...)
+		default:
+			// Scalars, single column arrays and messages with cell converters
+			columns = append(columns, schemaColumn{Name: columnName, RawName: rawColumnName, Path: path, Field: field})
+		}
+	}
+	return columns
+}
+
+// getDescriptorComment returns the proto comment attached to a field, message, enum or enum value
+func getDescriptorComment(descriptor desc.Descriptor) string {
+	sourceInfo := descriptor.GetSourceInfo()
+	if sourceInfo == nil {
+		return ""
+	}
+	comment := strings.TrimSpace(sourceInfo.GetLeadingComments())
+	if comment == "" {
+		comment = strings.TrimSpace(sourceInfo.GetTrailingComments())
+	}
+	return comment
+}
+
+// getFieldDescription returns the (text) option and comment of a field as documentation lines
+func getFieldDescription(field *desc.FieldDescriptor) []string {
+	var lines []string
+	if text := getFieldText(field); text != "" {
+		lines = append(lines, text)
+	}
+	if comment := getDescriptorComment(field); comment != "" {
+		lines = append(lines, comment)
+	}
+	return lines
+}
+
+// getEnumDisplayNames returns the alias of each enum value, or its name if no alias is set
+func getEnumDisplayNames(enumDesc *desc.EnumDescriptor) []string {
+	names := make([]string, 0, len(enumDesc.GetValues()))
+	for _, enumVal := range enumDesc.GetValues() {
+		names = append(names, getEnumDisplayName(enumVal))
+	}
+	return names
+}
+
+// getEnumDisplayName returns the alias of an enum value, or its name if no alias is set
+func getEnumDisplayName(enumVal *desc.EnumValueDescriptor) string {
+	if opts := enumVal.GetEnumValueOptions(); opts != nil {
+		if ext, ok := proto.GetExtension(opts, E_Alias).(string); ok && ext != "" {
+			return ext
+		}
+	}
+	return enumVal.GetName()
+}
+
+// GenerateTemplateFiles parses proto files and writes an Excel template for each table message
+func GenerateTemplateFiles(protoFile string, importPaths []string, config *TemplateConfig) error {
+	fileDescriptors, err := LoadProtoFiles(protoFile, importPaths)
+	if err != nil {
+		return err
+	}
+
+	// Group sheets by workbook so that tables sharing a file end up in one template
+	workbookPaths, workbookMessages, err := groupMessagesByWorkbook(FindExcelMessages(fileDescriptors), config.MessageName, config.OutputPath)
+	if err != nil {
+		return err
+	}
+
+	for _, excelPath := range workbookPaths {
+		if err := GenerateTemplate(workbookMessages[excelPath], excelPath, config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// groupMessagesByWorkbook groups table messages by their Excel file, optionally filtering by message name
+func groupMessagesByWorkbook(messages []*desc.MessageDescriptor, messageName, outputPath string) ([]string, map[string][]*desc.MessageDescriptor, error) {
+	var workbookPaths []string
+	workbookMessages := make(map[string][]*desc.MessageDescriptor)
+	for _, md := range messages {
+		if messageName != "" && md.GetName() != messageName && md.GetFullyQualifiedName() != messageName {
+			continue
+		}
+
+		excelPath, _, err := getExcelOptions(md)
+		if err != nil {
+			return nil, nil, err
+		}
+		if outputPath != "" {
+			excelPath = outputPath
+		}
+		if _, ok := workbookMessages[excelPath]; !ok {
+			workbookPaths = append(workbookPaths, excelPath)
+		}
+		workbookMessages[excelPath] = append(workbookMessages[excelPath], md)
+	}
+
+	if len(workbookPaths) == 0 {
+		if messageName != "" {
+			return nil, nil, fmt.Errorf("message %s not found or missing excel option", messageName)
+		}
+		return nil, nil, fmt.Errorf("no message with excel option found")
+	}
+	return workbookPaths, workbookMessages, nil
+}
+
+// GenerateTemplate writes an Excel workbook with one template sheet per message
+func GenerateTemplate(messages []*desc.MessageDescriptor, excelPath string, config *TemplateConfig) error {
+	if !config.Overwrite {
+		if _, err := os.Stat(excelPath); err == nil {
+			return fmt.Errorf("excel file %s already exists", excelPath)
+		}
+	}
+
+	excelFile := excelize.NewFile()
+	defer excelFile.Close()
+	defaultSheet := excelFile.GetSheetName(0)
+
+	for i, md := range messages {
+		_, sheetName, err := getExcelOptions(md)
+		if err != nil {
+			return err
+		}
+
+		if i == 0 {
+			if err := excelFile.SetSheetName(defaultSheet, sheetName); err != nil {
+				return fmt.Errorf("failed to rename sheet %s: %v", defaultSheet, err)
+			}
+		} else if _, err := excelFile.NewSheet(sheetName); err != nil {
+			return fmt.Errorf("failed to create sheet %s: %v", sheetName, err)
+		}
+
+		if err := writeTemplateSheet(excelFile, sheetName, md, config); err != nil {
+			return fmt.Errorf("failed to write template for message %s: %v", md.GetName(), err)
+		}
+	}
+
+	if err := excelFile.SaveAs(excelPath); err != nil {
+		return fmt.Errorf("failed to save excel file %s: %v", excelPath, err)
+	}
+
+	fmt.Printf("Generated template file: %s\n", excelPath)
+	return nil
+}
+
+// writeTemplateSheet writes the header row, comments and enum dropdowns of a message into a sheet
+func writeTemplateSheet(excelFile *excelize.File, sheetName string, msgDesc *desc.MessageDescriptor, config *TemplateConfig) error {
+	columns := collectSchemaColumns(msgDesc, "", "", "", fixedArraySlots(config.ArraySlots))
+
+	headerStyle, err := newHeaderStyle(excelFile)
+	if err != nil {
+		return err
+	}
+
+	for index, column := range columns {
+		if err := writeTemplateHeader(excelFile, sheetName, index+1, column, headerStyle); err != nil {
+			return err
+		}
+	}
+
+	// Record field paths so that later syncs can follow renamed headers
+	if err := writeColumnMeta(excelFile, sheetName, columns); err != nil {
+		return fmt.Errorf("failed to write column metadata: %v", err)
+	}
+
+	// Keep the header row visible while scrolling
+	return excelFile.SetPanes(sheetName, &excelize.Panes{
+		Freeze:      true,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	})
+}
+
+// newHeaderStyle creates the cell style used for generated header cells
+func newHeaderStyle(excelFile *excelize.File) (int, error) {
+	headerStyle, err := excelFile.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"DDEBF7"}},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create header style: %v", err)
+	}
+	return headerStyle, nil
+}
+
+// writeTemplateHeader writes a header cell with its comment and enum dropdown
+func writeTemplateHeader(excelFile *excelize.File, sheetName string, colIndex int, column schemaColumn, headerStyle int) error {
+	cell, err := excelize.CoordinatesToCellName(colIndex, 1)
+	if err != nil {
+		return err
+	}
+	if err := excelFile.SetCellStr(sheetName, cell, column.Name); err != nil {
+		return fmt.Errorf("failed to write header %s: %v", column.Name, err)
+	}
+	if err := excelFile.SetCellStyle(sheetName, cell, cell, headerStyle); err != nil {
+		return fmt.Errorf("failed to style header %s: %v", column.Name, err)
+	}
+
+	// Header comment from the proto field comment
+	if comment := getDescriptorComment(column.Field); comment != "" {
+		if err := excelFile.AddComment(sheetName, excelize.Comment{
+			Author: TemplateCommentAuthor,
+			Cell:   cell,
+			Text:   comment,
+		}); err != nil {
+			return fmt.Errorf("failed to add comment for %s: %v", column.Name, err)
+		}
+	}
+
+	// Dropdown of enum aliases for single enum columns
+	if column.Field.GetType().String() == "TYPE_ENUM" && !column.Field.IsRepeated() {
+		if err := addEnumDropList(excelFile, sheetName, colIndex, column.Field.GetEnumType()); err != nil {
+			return fmt.Errorf("failed to add dropdown for %s: %v", column.Name, err)
+		}
+	}
+	return nil
+}
+
+// addEnumDropList adds an enum dropdown validation to all data cells of a column
+func addEnumDropList(excelFile *excelize.File, sheetName string, colIndex int, enumDesc *desc.EnumDescriptor) error {
+	colName, err := excelize.ColumnNumberToName(colIndex)
+	if err != nil {
+		return err
+	}
+
+	names := getEnumDisplayNames(enumDesc)
+	validation := excelize.NewDataValidation(true)
+	validation.Sqref = fmt.Sprintf("%s2:%s%d", colName, colName, excelize.TotalRows)
+	if err := validation.SetDropList(names); err != nil {
+		if !errors.Is(err, excelize.ErrDataValidationFormulaLength) {
+			return err
+		}
+		// Inline lists are limited to 255 characters, reference a hidden sheet instead
+		sqref, err := writeEnumListSheet(excelFile, enumDesc, names)
+		if err != nil {
+			return err
+		}
+		validation.SetSqrefDropList(sqref)
+	}
+	validation.SetError(excelize.DataValidationErrorStyleStop, enumDesc.GetName(), "Please select a value from the dropdown list")
+	return excelFile.AddDataValidation(sheetName, validation)
+}
+
+// writeEnumListSheet writes enum names into a column of the hidden enum sheet and returns its reference
+func writeEnumListSheet(excelFile *excelize.File, enumDesc *desc.EnumDescriptor, names []string) (string, error) {
+	index, err := excelFile.GetSheetIndex(TemplateEnumSheet)
+	if err != nil {
+		return "", err
+	}
+	if index < 0 {
+		if _, err := excelFile.NewSheet(TemplateEnumSheet); err != nil {
+			return "", err
+		}
+		if err := excelFile.SetSheetVisible(TemplateEnumSheet, false); err != nil {
+			return "", err
+		}
+	}
+
+	// Reuse the column if this enum was written before, otherwise take the next free one
+	cols, err := excelFile.GetCols(TemplateEnumSheet)
+	if err != nil {
+		return "", err
+	}
+	colIndex := len(cols) + 1
+	for i, col := range cols {
+		if len(col) > 0 && col[0] == enumDesc.GetFullyQualifiedName() {
+			colIndex = i + 1
+			break
+		}
+	}
+	colName, err := excelize.ColumnNumberToName(colIndex)
+	if err != nil {
+		return "", err
+	}
+
+	values := append([]string{enumDesc.GetFullyQualifiedName()}, names...)
+	if err := excelFile.SetSheetCol(TemplateEnumSheet, colName+"1", &values); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("'%s'!$%s$2:$%s$%d", TemplateEnumSheet, colName, colName, len(values)), nil
+}